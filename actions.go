@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//
+// In a Github Actions workflow, everything we need to know is already in the
+// environment: the token, the repo the workflow runs in, and the server it
+// runs against.  The HTML report goes to a file for upload-artifact to pick
+// up, and the Markdown version goes to the job's step summary.
+//
+
+const defaultActionsOut string = "ghreview/report.html"
+
+// setupActions fills in whatever the command line left out from the Actions
+// environment.
+func setupActions(repos []string) []string {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		log.Printf("--actions given, but this doesn't look like a Github Actions runner")
+	}
+
+	prefix := ""
+	if server, err := url.Parse(os.Getenv("GITHUB_SERVER_URL")); err == nil && server.Host != "" && server.Host != defaultHost {
+		//
+		// Running on Github Enterprise; GITHUB_TOKEN is for that instance.
+		//
+		if config.Hosts == nil {
+			config.Hosts = map[string]HostConfig{}
+		}
+		hc := config.Hosts[server.Host]
+		if hc.API == "" {
+			hc.API = os.Getenv("GITHUB_API_URL")
+		}
+		if hc.Token == "" && hc.TokenEnv == "" {
+			hc.TokenEnv = "GITHUB_TOKEN"
+		}
+		config.Hosts[server.Host] = hc
+		prefix = server.Host + "/"
+	}
+
+	if len(repos) == 0 {
+		repo := os.Getenv("GITHUB_REPOSITORY")
+		if repo == "" {
+			log.Fatal("no repos given and GITHUB_REPOSITORY is not set")
+		}
+		repos = []string{prefix + repo}
+	}
+	return repos
+}
+
+func writeStepSummary(results []RepoResult, year int) {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatalf("unable to open the step summary: %s", err)
+	}
+	defer f.Close()
+	if err := renderMarkdown(f, results, year); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeActionsOutput tells later steps where the report ended up.
+func writeActionsOutput(reportPaths []string) {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatalf("unable to open the step outputs: %s", err)
+	}
+	defer f.Close()
+	//
+	// With one report per repo, report is the first, and reports has them
+	// all, a line each.
+	//
+	var abs []string
+	for _, p := range reportPaths {
+		a, _ := filepath.Abs(p)
+		abs = append(abs, a)
+	}
+	fmt.Fprintf(f, "report=%s\n", abs[0])
+	fmt.Fprintf(f, "reports<<EOF\n%s\nEOF\n", strings.Join(abs, "\n"))
+}
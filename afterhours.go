@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+//
+// With --after-hours, contributions made on weekends or outside working
+// hours are marked, and each repo says what share of them that was.  Working
+// hours are 09:00 to 18:00, Monday to Friday, in the configured time zone,
+// unless the config says otherwise:
+//
+//	"WorkingHours": {"Start": "08:30", "End": "17:00", "Days": ["Sun", "Mon", "Tue", "Wed", "Thu"]}
+//
+
+var afterHours bool
+
+type WorkingHoursConfig struct {
+	Start string
+	End   string
+	Days  []string
+}
+
+var (
+	workStart = 9 * time.Hour
+	workEnd   = 18 * time.Hour
+	workDays  = map[time.Weekday]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}
+)
+
+func checkWorkingHours() {
+	wh := config.WorkingHours
+	if wh == nil {
+		return
+	}
+	if wh.Start != "" {
+		workStart = parseClock(wh.Start)
+	}
+	if wh.End != "" {
+		workEnd = parseClock(wh.End)
+	}
+	if workEnd <= workStart {
+		log.Fatalf("WorkingHours must end after they start, not at %s", wh.End)
+	}
+	if len(wh.Days) > 0 {
+		workDays = map[time.Weekday]bool{}
+		for _, day := range wh.Days {
+			workDays[parseWeekday(day)] = true
+		}
+	}
+}
+
+// parseClock turns 09:30 into how long after midnight that is.
+func parseClock(s string) time.Duration {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		log.Fatalf("expected a time like 09:30 in WorkingHours, got %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+func parseWeekday(s string) time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d
+		}
+	}
+	log.Fatalf("unknown day %q in WorkingHours", s)
+	return time.Sunday
+}
+
+// isAfterHours says whether the raw timestamp falls outside working hours.
+func isAfterHours(raw string) bool {
+	t, err := parseTimestamp(raw)
+	if err != nil {
+		return false
+	}
+	if !workDays[t.Weekday()] {
+		return true
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	since := t.Sub(midnight)
+	return since < workStart || since >= workEnd
+}
+
+// contributedAt is when I made my contribution to the PR: when it was
+// merged, when I first reviewed it, or for PRs I authored, its anchor time.
+func contributedAt(repo string, p Pull) string {
+	switch p.MyContribution {
+	case "merged":
+		return p.MergedAt
+	case "reviewed":
+		for _, r := range reviewsOf(repo, p) {
+			if isMe(r.User.Login) && r.SubmittedAt != "" {
+				return r.SubmittedAt
+			}
+		}
+	}
+	return p.Timestamp
+}
+
+func markAfterHours(result *RepoResult) {
+	result.AfterHours = 0
+	for i := range result.Pulls {
+		result.Pulls[i].AfterHours = isAfterHours(contributedAt(result.Name, result.Pulls[i]))
+		if result.Pulls[i].AfterHours {
+			result.AfterHours++
+		}
+	}
+}
+
+// AfterHoursShare is the share of the repo's contributions made after
+// hours, as a percentage.
+func (result RepoResult) AfterHoursShare() string {
+	if len(result.Pulls) == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", result.AfterHours*100/len(result.Pulls))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//
+// Most of what we do is reading, which goes through fetch and the cache.
+// These are for the rest: writing to the API, and reads that have to be
+// live because we're about to write based on them.
+//
+
+// apiRequest sends body (if any) as JSON and decodes the response into v (if
+// any), bypassing the cache.
+func apiRequest(method string, url string, body any, v any) {
+	status, data := apiSend(method, url, body)
+	if status > 299 {
+		log.Fatalf("%s %s: HTTP %d: %s", method, url, status, data)
+	}
+	decodeAPI(data, v)
+}
+
+// apiLookup is a live GET for something that may legitimately not exist.
+func apiLookup(url string, v any) bool {
+	status, data := apiSend(http.MethodGet, url, nil)
+	if status == http.StatusNotFound {
+		return false
+	} else if status > 299 {
+		log.Fatalf("GET %s: HTTP %d: %s", url, status, data)
+	}
+	decodeAPI(data, v)
+	return true
+}
+
+func decodeAPI(data []byte, v any) {
+	if v != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, v); err != nil {
+			log.Fatalf("JSON unmarshalling failed: %s", err)
+		}
+	}
+}
+
+func apiSend(method string, url string, body any) (int, []byte) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	ctx := context.WithValue(context.Background(), bypassCache{}, true)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return resp.StatusCode, data
+}
+
+func graphqlURL(host *Host) string {
+	if host.Name == defaultHost && host.API == "https://api.github.com" {
+		return "https://api.github.com/graphql"
+	}
+	return strings.TrimSuffix(host.API, "/v3") + "/graphql"
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage
+	Errors []struct {
+		Message string
+	}
+}
+
+// graphql runs query against host and decodes its data into v.
+func graphql(host *Host, query string, variables map[string]any, v any) {
+	var resp graphqlResponse
+	apiRequest(http.MethodPost, graphqlURL(host), map[string]any{"query": query, "variables": variables}, &resp)
+	resp.decode(v)
+}
+
+func (resp graphqlResponse) decode(v any) {
+	if len(resp.Errors) > 0 {
+		var messages []string
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		log.Fatalf("GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+	if v != nil {
+		if err := json.Unmarshal(resp.Data, v); err != nil {
+			log.Fatalf("JSON unmarshalling failed: %s", err)
+		}
+	}
+}
+
+func splitOwner(name string) (string, string) {
+	owner, repo, ok := strings.Cut(name, "/")
+	if !ok {
+		log.Fatalf("expected owner/repo, got %q", name)
+	}
+	return owner, repo
+}
@@ -0,0 +1,75 @@
+package main
+
+import "fmt"
+
+//
+// How often I approve and how often I ask for changes says more about my
+// reviewing than how many PRs I looked at.  With --review-stats, each repo
+// breaks my reviews down by verdict, and says how many rounds of my reviews
+// the PRs I reviewed took before they were merged.  As with --review-depth,
+// use --reviews too to include the PRs I only reviewed.
+//
+
+var reviewStats bool
+
+type ReviewStats struct {
+	Approved         int
+	ChangesRequested int
+	Commented        int
+	// Rounds is how many of my reviews came before the merge, over Merged
+	// PRs.
+	Rounds int
+	Merged int
+}
+
+func (s ReviewStats) Total() int {
+	return s.Approved + s.ChangesRequested + s.Commented
+}
+
+// ApprovalRatio is the share of my reviews that were approvals.
+func (s ReviewStats) ApprovalRatio() string {
+	if s.Total() == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", s.Approved*100/s.Total())
+}
+
+func (s ReviewStats) AverageRounds() string {
+	return average(s.Rounds, s.Merged)
+}
+
+func loadReviewStats(repo string, pulls []Pull) *ReviewStats {
+	var stats ReviewStats
+	for _, p := range pulls {
+		if p.MyContribution == "authored" {
+			continue
+		}
+		merged, err := parseTimestamp(p.MergedAt)
+		isMerged := err == nil
+
+		rounds := 0
+		for _, r := range reviewsOf(repo, p) {
+			if !isMe(r.User.Login) {
+				continue
+			}
+			switch r.State {
+			case "APPROVED":
+				stats.Approved++
+			case "CHANGES_REQUESTED":
+				stats.ChangesRequested++
+			case "COMMENTED":
+				stats.Commented++
+			default:
+				continue
+			}
+			if submitted, err := parseTimestamp(r.SubmittedAt); isMerged && err == nil && !submitted.After(merged) {
+				rounds++
+			}
+		}
+		if isMerged && rounds > 0 {
+			stats.Rounds += rounds
+			stats.Merged++
+		}
+	}
+	return &stats
+}
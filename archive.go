@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+)
+
+//
+// An archive is a tarball of every cache entry that a year's report depends
+// on.  Unpacking it in the working directory restores the cache/ subdirectory,
+// after which the report re-renders without touching the network, even if
+// the PRs or the repos themselves have since disappeared from github.
+//
+
+const manifestName string = "ghreview-archive.json"
+
+type Manifest struct {
+	Year    int
+	Repos   []string
+	Created string
+}
+
+func archiveMain(args []string) {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	year := flags.Int("year", defaultYear, "the year to archive")
+	out := flags.String("out", "", "where to write the archive (.tar, .tar.gz or .tar.zst)")
+	parseFlags(flags, args)
+
+	repos := flags.Args()
+	if *out == "" || len(repos) == 0 {
+		log.Fatal("usage: ghreview archive --year 2021 --out 2021.tar.zst owner/repo...")
+	}
+
+	//
+	// Collecting the report fills in any cache misses and tells us which
+	// entries were actually needed.
+	//
+	for _, repo := range repos {
+		collectRepo(repo, *year)
+	}
+
+	manifest := Manifest{*year, repos, time.Now().UTC().Format(time.RFC3339)}
+	keys := touchedKeys()
+	writeArchive(*out, manifest, keys)
+	log.Printf("archived %d cache entries to %s", len(keys), *out)
+}
+
+// Exports are the same thing as archives, except that they can also cover
+// everything we have cached for a repo, regardless of year.  They're meant
+// for handing a big crawl over to somebody else so they don't have to repeat
+// it.
+func exportMain(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	year := flags.Int("year", 0, "only export the entries needed for this year's report")
+	out := flags.String("out", "", "where to write the bundle (.tar, .tar.gz or .tar.zst)")
+	parseFlags(flags, args)
+
+	repos := flags.Args()
+	if *out == "" || len(repos) == 0 {
+		log.Fatal("usage: ghreview export [--year 2021] --out bundle.tar.gz owner/repo...")
+	}
+
+	if *year != 0 {
+		for _, repo := range repos {
+			collectRepo(repo, *year)
+		}
+	} else {
+		for _, repo := range repos {
+			walkCache(repo)
+		}
+	}
+
+	manifest := Manifest{*year, repos, time.Now().UTC().Format(time.RFC3339)}
+	keys := touchedKeys()
+	writeArchive(*out, manifest, keys)
+	log.Printf("exported %d cache entries to %s", len(keys), *out)
+}
+
+func walkCache(repo string) {
+	keys, err := cache.Keys(repoKeyPrefix(repo))
+	if err != nil {
+		log.Fatalf("unable to read the cache for %s: %s", repo, err)
+	}
+	for _, key := range keys {
+		touched[key] = true
+	}
+}
+
+func importMain(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	overwrite := flags.Bool("overwrite", false, "replace cache entries that already exist")
+	parseFlags(flags, args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("usage: ghreview import [--overwrite] bundle.tar.gz...")
+	}
+	for _, path := range flags.Args() {
+		imported, skipped := readArchive(path, *overwrite)
+		log.Printf("imported %d cache entries from %s, skipped %d existing", imported, path, skipped)
+	}
+}
+
+func readArchive(archive string, overwrite bool) (imported int, skipped int) {
+	in := openCompressed(archive)
+	defer in.Close()
+
+	tr := tar.NewReader(in)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatalf("unable to read %s: %s", archive, err)
+		}
+		if hdr.Name == manifestName || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		//
+		// Don't let a bundle write anywhere outside of the cache.
+		//
+		name := path.Clean(hdr.Name)
+		if !strings.HasPrefix(name, "cache/") || strings.Contains(name, "..") {
+			log.Printf("ignoring suspicious entry %s in %s", hdr.Name, archive)
+			continue
+		}
+		key := strings.TrimPrefix(name, "cache/")
+
+		if _, err := cache.Get(key); err == nil && !overwrite {
+			skipped++
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			log.Fatalf("unable to read %s from %s: %s", hdr.Name, archive, err)
+		}
+		writeCache(key, data)
+		imported++
+	}
+	return imported, skipped
+}
+
+func writeArchive(path string, manifest Manifest, keys []string) {
+	out := createCompressed(path)
+	tw := tar.NewWriter(out)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	addToArchive(tw, manifestName, data)
+
+	for _, key := range keys {
+		data, err := cache.Get(key)
+		if err != nil {
+			log.Fatalf("unable to read %s from the cache: %s", key, err)
+		}
+		addToArchive(tw, "cache/"+key, data)
+	}
+
+	if err := tw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("unable to write %s: %s", path, err)
+	}
+}
+
+func addToArchive(tw *tar.Writer, name string, data []byte) {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// There is no zstd in the standard library, so for .zst we pipe through the
+// zstd binary instead of pulling in a dependency.
+type zstdWriter struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (z *zstdWriter) Close() error {
+	if err := z.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return z.cmd.Wait()
+}
+
+type gzipWriter struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (g *gzipWriter) Close() error {
+	if err := g.Writer.Close(); err != nil {
+		return err
+	}
+	return g.file.Close()
+}
+
+func createCompressed(path string) io.WriteCloser {
+	if strings.HasSuffix(path, ".zst") {
+		cmd := exec.Command("zstd", "-q", "-f", "-o", path)
+		cmd.Stderr = os.Stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			log.Fatalf("unable to run zstd, is it installed? %s", err)
+		}
+		return &zstdWriter{stdin, cmd}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("unable to create %s: %s", path, err)
+	}
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		return &gzipWriter{gzip.NewWriter(file), file}
+	}
+	return file
+}
+
+type zstdReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (z *zstdReader) Close() error {
+	z.ReadCloser.Close()
+	return z.cmd.Wait()
+}
+
+type gzipReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipReader) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+func openCompressed(path string) io.ReadCloser {
+	if strings.HasSuffix(path, ".zst") {
+		cmd := exec.Command("zstd", "-q", "-d", "-c", path)
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			log.Fatalf("unable to run zstd, is it installed? %s", err)
+		}
+		return &zstdReader{stdout, cmd}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("unable to open %s: %s", path, err)
+	}
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			log.Fatalf("unable to decompress %s: %s", path, err)
+		}
+		return &gzipReader{gz, file}
+	}
+	return file
+}
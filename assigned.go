@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sort"
+)
+
+//
+// Some work is owning things rather than writing them: triaging, chasing,
+// shepherding a PR somebody else wrote.  With --assigned, we read the repo's
+// issue events for the issues and PRs that were assigned to me during the
+// period, and list the ones that aren't already in the report as something
+// I authored, merged or reviewed.  Something assigned to me before the
+// period started doesn't count, since finding those would mean reading the
+// repo's whole history.
+//
+
+var assignedWork bool
+
+const issueEventsPageSize int = 100
+
+type IssueEvent struct {
+	Event     string
+	CreatedAt string `json:"created_at"`
+	Assignee  *User
+	Issue     Issue
+}
+
+type Assignment struct {
+	Issue
+	// AssignedAt is when it was (first) assigned to me during the period.
+	AssignedAt string
+}
+
+func loadAssigned(repo string, year int, pulls []Pull) []Assignment {
+	counted := map[int]bool{}
+	for _, p := range pulls {
+		counted[p.Number] = true
+	}
+	first, _ := periodBounds(year)
+
+	found := map[int]Assignment{}
+	for page := 1; ; page++ {
+		var events []IssueEvent
+		fetch(repoURL(repo, "/issues/events?per_page=%d&page=%d", issueEventsPageSize, page), &events)
+
+		//
+		// The events come newest first, so we can stop at the first page
+		// that reaches back past the start of the period.
+		//
+		done := len(events) < issueEventsPageSize
+		for _, e := range events {
+			ts, err := parseTimestamp(e.CreatedAt)
+			if err != nil {
+				continue
+			}
+			if ts.Before(first) {
+				done = true
+				continue
+			}
+			if e.Event != "assigned" || e.Assignee == nil || !isMe(e.Assignee.Login) || !inPeriod(ts, year) {
+				continue
+			}
+			n := e.Issue.Number
+			if counted[n] || ignored(repo, n) {
+				continue
+			}
+			if a, ok := found[n]; !ok || e.CreatedAt < a.AssignedAt {
+				found[n] = Assignment{e.Issue, e.CreatedAt}
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	var assigned []Assignment
+	for _, a := range found {
+		assigned = append(assigned, a)
+	}
+	sort.Slice(assigned, func(i, j int) bool { return assigned[i].Number > assigned[j].Number })
+	return assigned
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//
+// Working out who merged each PR, and whether I reviewed it, costs a REST
+// call per PR, which adds up to hundreds per repo.  GraphQL lets us ask about
+// many PRs at once by giving each its own alias, so with --graphql we look up
+// a whole page's worth in a couple of queries.  The queries are cached like
+// any GET, so archives still re-render offline.
+//
+
+// GraphQL limits how much a single query may ask for, and 50 PRs with their
+// reviews stays well under that.
+const graphqlBatch int = 50
+
+var useGraphQL bool
+
+var countReviews bool
+
+type graphqlPull struct {
+	MergedBy *User
+	Reviews  struct {
+		Nodes []struct {
+			Author      *User
+			State       string
+			SubmittedAt string
+		}
+	}
+}
+
+// lookupPulls fills in MergedBy and the reviews for the PRs in year that
+// we'd otherwise have to make REST calls about.
+func lookupPulls(repo string, year int, pulls []Pull) {
+	var wanted []int
+	for i, p := range pulls {
+		if ts, ok := anchorTime(p); !ok || !inPeriod(ts, year) || isMe(p.User.Login) {
+			continue
+		}
+		if (p.State == "closed" && p.MergedBy == nil) || countReviews {
+			wanted = append(wanted, i)
+		}
+	}
+
+	host, name := splitRepo(repo)
+	if len(wanted) > 0 && host.Token == "" {
+		log.Fatalf("--graphql needs a token for %s", host.Name)
+	}
+	owner, repoName := splitOwner(name)
+
+	for len(wanted) > 0 {
+		batch := wanted
+		if len(batch) > graphqlBatch {
+			batch = batch[:graphqlBatch]
+		}
+		wanted = wanted[len(batch):]
+
+		var b strings.Builder
+		b.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+		for _, i := range batch {
+			fmt.Fprintf(&b, " pr%d: pullRequest(number: %d) { mergedBy { login } reviews(first: 100) { nodes { author { login } state submittedAt } } }", pulls[i].Number, pulls[i].Number)
+		}
+		b.WriteString(" } }")
+
+		var data struct {
+			Repository map[string]*graphqlPull
+		}
+		cachedGraphQL(host, b.String(), map[string]any{"owner": owner, "name": repoName}, &data)
+
+		for _, i := range batch {
+			gp := data.Repository[fmt.Sprintf("pr%d", pulls[i].Number)]
+			if gp == nil {
+				continue
+			}
+
+			//
+			// A PR that wasn't merged has no merger, and saying so explicitly
+			// keeps whoMerged from going to the events.
+			//
+			if gp.MergedBy != nil {
+				pulls[i].MergedBy = gp.MergedBy
+			} else {
+				pulls[i].MergedBy = &User{"nobody"}
+			}
+
+			pulls[i].reviews = nil
+			for _, r := range gp.Reviews.Nodes {
+				review := Review{State: r.State, SubmittedAt: r.SubmittedAt}
+				if r.Author != nil {
+					review.User = *r.Author
+				}
+				pulls[i].reviews = append(pulls[i].reviews, review)
+			}
+			pulls[i].haveReviews = true
+		}
+	}
+}
+
+// cachedGraphQL is graphql for queries, which go through the cache.
+func cachedGraphQL(host *Host, query string, variables map[string]any, v any) {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), cacheablePost{}, true)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, graphqlURL(host), bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StatusCode > 299 {
+		log.Fatalf("POST %s: HTTP %d: %s", req.URL, resp.StatusCode, data)
+	}
+
+	var gr graphqlResponse
+	decodeAPI(data, &gr)
+	gr.decode(v)
+}
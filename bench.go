@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//
+// To catch throughput regressions we need numbers, and the real API is both
+// too slow and too rate-limited to get them from.  So bench runs the whole
+// pipeline (collect and render) against a fake API that makes up repos of
+// whatever size we like, and reports requests/sec and allocations.  With
+// --serve, it just serves the fake API, for pointing other runs at via the
+// Hosts section of the config.
+//
+
+const benchHost string = "bench"
+
+// fakeAPI serves synthetic repos with pulls many PRs each, spread over year
+// and a bit of the year before, so that collection terminates the usual way.
+type fakeAPI struct {
+	pulls    int
+	pageSize int
+	year     int
+	latency  time.Duration
+	login    string
+	requests atomic.Int64
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	time.Sleep(f.latency)
+
+	// /repos/owner/repo/...
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "repos" {
+		http.NotFound(w, r)
+		return
+	}
+	rest := parts[3:]
+
+	var body any
+	switch {
+	case len(rest) == 1 && rest[0] == "pulls":
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		body = f.pullPage(parts[1]+"/"+parts[2], page)
+	case len(rest) == 3 && rest[0] == "issues" && rest[2] == "events":
+		number, _ := strconv.Atoi(rest[1])
+		body = f.events(number)
+	case len(rest) == 3 && rest[0] == "pulls" && rest[2] == "reviews":
+		body = []Review{}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeAPI) pull(repo string, number int) Pull {
+	//
+	// Number 1 is the oldest, and the newest lands on the last day of year.
+	//
+	end := time.Date(f.year, 12, 31, 12, 0, 0, 0, time.UTC)
+	step := 400 * 24 * time.Hour / time.Duration(f.pulls)
+	created := end.Add(-time.Duration(f.pulls-number) * step)
+
+	p := Pull{
+		Number:    number,
+		HtmlUrl:   fmt.Sprintf("https://github.com/%s/pull/%d", repo, number),
+		CreatedAt: created.Format(time.RFC3339),
+		UpdatedAt: created.Format(time.RFC3339),
+		State:     "open",
+		Title:     fmt.Sprintf("Synthetic change #%d", number),
+		User:      User{"someone"},
+	}
+	if number%4 == 0 {
+		p.User = User{f.login}
+	}
+	if number%2 == 0 {
+		p.State = "closed"
+		p.ClosedAt = created.Add(time.Hour).Format(time.RFC3339)
+		p.UpdatedAt = p.ClosedAt
+	}
+	if number%6 == 0 {
+		p.MergedAt = p.ClosedAt
+	}
+	return p
+}
+
+func (f *fakeAPI) pullPage(repo string, page int) []Pull {
+	pulls := []Pull{}
+	first := f.pulls - (page-1)*f.pageSize
+	for n := first; n > 0 && n > first-f.pageSize; n-- {
+		pulls = append(pulls, f.pull(repo, n))
+	}
+	return pulls
+}
+
+func (f *fakeAPI) events(number int) []Event {
+	events := []Event{{User{"someone"}, "closed"}}
+	if number%6 == 0 {
+		merger := User{"someone"}
+		if number%12 == 0 {
+			merger = User{f.login}
+		}
+		events = append(events, Event{merger, "merged"})
+	}
+	return events
+}
+
+func benchMain(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	repos := flags.Int("repos", 10, "how many synthetic repos to collect")
+	pulls := flags.Int("pulls", 300, "how many PRs each synthetic repo has")
+	pageSize := flags.Int("page-size", 30, "how many PRs the fake API returns per page")
+	latency := flags.Duration("latency", 0, "how long the fake API takes to answer each request")
+	iterations := flags.Int("iterations", 3, "how many times to run the pipeline")
+	warm := flags.Bool("warm", false, "keep the cache between iterations, so that only the first one is cold")
+	serve := flags.String("serve", "", "instead of benchmarking, serve the fake API on this address")
+	parseFlags(flags, args)
+
+	api := &fakeAPI{pulls: *pulls, pageSize: *pageSize, year: defaultYear, latency: *latency, login: myLogins()[0]}
+	if *serve != "" {
+		log.Printf("serving synthetic repos on http://%s/", *serve)
+		log.Fatal(http.ListenAndServe(*serve, api))
+	}
+
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	if config.Hosts == nil {
+		config.Hosts = map[string]HostConfig{}
+	}
+	config.Hosts[benchHost] = HostConfig{API: srv.URL}
+	minInterval = 0
+
+	var names []string
+	for i := 0; i < *repos; i++ {
+		names = append(names, fmt.Sprintf("%s/synthetic/repo%d", benchHost, i))
+	}
+
+	//
+	// Benchmarks shouldn't disturb, or be helped by, the real cache.
+	//
+	dir, err := os.MkdirTemp("", "ghreview-bench")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Printf("%-10s %12s %10s %10s %12s %14s\n", "iteration", "elapsed", "requests", "req/s", "allocs", "bytes")
+	for i := 1; i <= *iterations; i++ {
+		if !*warm {
+			os.RemoveAll(dir)
+		}
+		cache = fileCache{dir}
+		touched = map[string]bool{}
+		api.requests.Store(0)
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+
+		var results []RepoResult
+		for _, repo := range names {
+			result := collectRepo(repo, defaultYear)
+			result.Score = score(result.Pulls)
+			results = append(results, result)
+		}
+		if err := renderHTML(io.Discard, results, defaultYear); err != nil {
+			log.Fatal(err)
+		}
+
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		requests := api.requests.Load()
+		fmt.Printf("%-10d %12s %10d %10.1f %12d %14d\n",
+			i, elapsed.Round(time.Millisecond), requests, float64(requests)/elapsed.Seconds(),
+			after.Mallocs-before.Mallocs, after.TotalAlloc-before.TotalAlloc)
+	}
+}
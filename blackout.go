@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+//
+// A quiet stretch because of a sabbatical or parental leave isn't a slump,
+// and shouldn't drag the numbers down as if it were.  The config can list
+// the time away, e.g.
+//
+//	"Blackouts": [{"Name": "parental leave", "From": "2021-03-01", "To": "2021-05-31"}]
+//
+// and the monthly scores then shade those months, and leave the months that
+// were away entirely out of the monthly average.
+//
+
+type BlackoutConfig struct {
+	Name string
+	From string
+	To   string
+}
+
+type blackout struct {
+	name     string
+	from, to time.Time
+}
+
+func blackouts() []blackout {
+	var all []blackout
+	for _, b := range config.Blackouts {
+		from, err := time.ParseInLocation(defaultTimeFormat, b.From, location())
+		if err != nil {
+			log.Fatalf("expected a date like 2021-03-01 for %q in Blackouts, got %q", b.Name, b.From)
+		}
+		to, err := time.ParseInLocation(defaultTimeFormat, b.To, location())
+		if err != nil {
+			log.Fatalf("expected a date like 2021-03-01 for %q in Blackouts, got %q", b.Name, b.To)
+		}
+		if to.Before(from) {
+			log.Fatalf("%q in Blackouts ends before it starts", b.Name)
+		}
+		all = append(all, blackout{b.Name, from, to.AddDate(0, 0, 1)})
+	}
+	return all
+}
+
+func blackoutsEnabled() bool {
+	return len(config.Blackouts) > 0
+}
+
+// markAway says which blackouts overlap each month, and whether they cover
+// all of it.
+func markAway(months []MonthScore) {
+	periods := blackouts()
+	for i := range months {
+		start, err := time.ParseInLocation("2006-01", months[i].Month, location())
+		if err != nil {
+			log.Fatal(err)
+		}
+		end := start.AddDate(0, 1, 0)
+		var names []string
+		for _, b := range periods {
+			if b.from.Before(end) && b.to.After(start) {
+				names = append(names, b.name)
+				if !b.from.After(start) && !b.to.Before(end) {
+					months[i].AwayAll = true
+				}
+			}
+		}
+		months[i].Away = strings.Join(names, ", ")
+	}
+}
+
+// activeAverage is the average monthly score, over the months that weren't
+// spent away entirely.
+func activeAverage(months []MonthScore) string {
+	var total float64
+	active := 0
+	for _, m := range months {
+		if !m.AwayAll {
+			total += m.Score
+			active++
+		}
+	}
+	if active == 0 {
+		return "0 (away all year)"
+	}
+	return fmt.Sprintf("%.1f over %d months", total/float64(active), active)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log"
+	"path"
+	"sync"
+)
+
+//
+// Release managers merge a lot of backports, and experimental branches see
+// a lot of churn; neither is necessarily what a report should count.  With
+// --default-branch and --base-branch, only PRs into the chosen branches do.
+//
+
+var defaultBranchOnly bool
+
+var baseBranches stringList
+
+var (
+	defaultBranches   = map[string]string{}
+	defaultBranchesMu sync.Mutex
+)
+
+func filteringBranches() bool {
+	return defaultBranchOnly || len(baseBranches) > 0
+}
+
+func defaultBranch(repo string) string {
+	defaultBranchesMu.Lock()
+	defer defaultBranchesMu.Unlock()
+
+	if branch, ok := defaultBranches[repo]; ok {
+		return branch
+	}
+	var r struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	fetch(repoURL(repo, ""), &r)
+	defaultBranches[repo] = r.DefaultBranch
+	return r.DefaultBranch
+}
+
+// countsBase says whether the PR went into a branch we're counting.
+func countsBase(repo string, pull Pull) bool {
+	if !filteringBranches() {
+		return true
+	}
+	if defaultBranchOnly && pull.Base.Ref == defaultBranch(repo) {
+		return true
+	}
+	for _, pattern := range baseBranches {
+		matched, err := path.Match(pattern, pull.Base.Ref)
+		if err != nil {
+			log.Fatalf("bad --base-branch pattern %q: %s", pattern, err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// The cache is yet another work-around for Github API rate limiting.  Keys
+// are slash-separated paths and values are opaque blobs; see httpcache.go for
+// what actually goes in there.
+//
+
+var errCacheMiss = errors.New("cache miss")
+
+type Cache interface {
+	// Get returns errCacheMiss if there is nothing stored under key.
+	Get(key string) ([]byte, error)
+	Put(key string, data []byte) error
+	Keys(prefix string) ([]string, error)
+}
+
+// Shared backends also implement Locker, so that when several people run
+// reports against the same backend only one of them fetches each object and
+// everybody else waits for their result.
+type Locker interface {
+	Lock(key string) (bool, error)
+	Unlock(key string) error
+}
+
+var cache Cache = fileCache{localCacheDir}
+
+var cacheURL string
+
+// Every cache key we read or write during a run ends up in here, so that we
+// can tell afterwards exactly which entries a report depended on.
+var touched = map[string]bool{}
+
+var touchedMu sync.Mutex
+
+// addCollectionFlags adds the flags that decide which PRs count and how,
+// which the browser build takes too.
+func addCollectionFlags(flags *flag.FlagSet) {
+	flags.DurationVar(&ttl, "ttl", 0, "revalidate cached responses older than this (0 means cached responses never expire)")
+	flags.StringVar(&user, "user", defaultUser, "whose contributions to report: a login, or the name of an identity in the config")
+	flags.BoolVar(&countReviews, "reviews", false, "also count PRs that I reviewed")
+	flags.Var(&mentees, "mentee", "also report on this person's PRs and how I reviewed and merged them, by login or identity (may be repeated)")
+	flags.BoolVar(&useGraphQL, "graphql", false, "look up mergers and reviews in batches via GraphQL (needs a token)")
+	flags.StringVar(&matchOn, "match-on", matchCreated, "which of a PR's timestamps decides the year it counts for: created, merged or closed")
+	flags.BoolVar(&excludeDrafts, "exclude-drafts", false, "leave out draft PRs, which were never finished")
+	flags.BoolVar(&defaultBranchOnly, "default-branch", false, "only count PRs into each repo's default branch (plus any --base-branch)")
+	flags.Var(&baseBranches, "base-branch", "only count PRs into branches matching this glob, e.g. release/* (may be repeated)")
+	flags.Var(&pathGlobs, "path", "only count PRs that change files matching this glob, e.g. src/subsystem/** (may be repeated)")
+	flags.BoolVar(&lateMerges, "late-merges", false, "also include PRs created before the year but merged during it")
+}
+
+// parseFlags adds the flags that every subcommand understands, parses args,
+// and sets up the things those flags control.
+func parseFlags(flags *flag.FlagSet, args []string) {
+	addCollectionFlags(flags)
+	flags.StringVar(&cacheURL, "cache", defaultCacheDir(), "where to cache API responses: a directory or redis://[:password@]host:port[/db]")
+	flags.StringVar(&configPath, "config", defaultConfigPath(), "where to read the config from")
+	flags.BoolVar(&useLocal, "local", false, "keep the cache and config in the working directory, as ./cache and ./ghreview.json")
+	flags.StringVar(&otlpEndpoint, "otlp", defaultOTLPEndpoint(), "send traces of the run to this OTLP/HTTP collector, e.g. http://localhost:4318")
+	flags.StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile of the run to this file")
+	flags.StringVar(&memProfile, "memprofile", "", "write a heap profile to this file at the end of the run")
+	flags.DurationVar(&minInterval, "min-interval", defaultMinInterval, "the least time between requests to the same host (we slow down further as the quota runs low)")
+	flags.IntVar(&prefetch, "prefetch", 1, "how many pages of PRs to fetch ahead of the one being classified (0 to disable)")
+	flags.BoolVar(&showTimings, "timings", false, "print how long each repo spent in each phase at the end of the run")
+	flags.BoolVar(&forceLock, "force", false, "use the cache even if another run seems to hold its lock")
+	flags.StringVar(&ignorePath, "ignore", defaultIgnorePath, "a file of owner/repo#number lines, one per PR to leave out")
+	flags.StringVar(&notesPath, "notes", defaultNotesPath, "a YAML file of notes to show next to PRs, keyed by owner/repo#number")
+	flags.BoolVar(&encryptCache, "encrypt", false, "encrypt cache entries, with a key from $"+cacheKeyEnv+" or the OS keyring")
+	flags.Parse(args)
+	applyLocal(flags)
+	if matchOn != matchCreated && matchOn != matchMerged && matchOn != matchClosed {
+		log.Fatalf("--match-on must be created, merged or closed, not %q", matchOn)
+	}
+	startTracing(flags.Name())
+	startProfiling()
+
+	var err error
+	if config, err = loadConfig(configPath); err != nil {
+		log.Fatalf("unable to load config %s: %s", configPath, err)
+	}
+	loadIgnored(ignorePath)
+	loadNotes(notesPath)
+	if cache, err = openCache(cacheURL); err != nil {
+		log.Fatalf("unable to open cache %s: %s", cacheURL, err)
+	}
+	if fc, ok := cache.(fileCache); ok {
+		lockCache(fc.dir)
+	}
+	if encryptCache {
+		cache = encrypt(cache)
+	}
+	checkSchema()
+}
+
+func openCache(location string) (Cache, error) {
+	if strings.HasPrefix(location, "redis://") {
+		return dialRedis(location)
+	}
+	return fileCache{location}, nil
+}
+
+func readCache(key string) ([]byte, error) {
+	span := startSpan("cache get", "key", key)
+	defer span.End()
+
+	data, err := cache.Get(key)
+	if err != nil {
+		span.set("hit", "false")
+		return nil, err
+	}
+	span.set("hit", "true")
+	touchedMu.Lock()
+	touched[key] = true
+	touchedMu.Unlock()
+	return data, nil
+}
+
+func writeCache(key string, data []byte) {
+	span := startSpan("cache put", "key", key)
+	defer span.End()
+
+	if err := cache.Put(key, data); err != nil {
+		log.Fatalf("unable to write %s to the cache: %s", key, err)
+	}
+	touchedMu.Lock()
+	touched[key] = true
+	touchedMu.Unlock()
+}
+
+func touchedKeys() []string {
+	var keys []string
+	for key := range touched {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type fileCache struct {
+	dir string
+}
+
+func (fc fileCache) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(fc.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errCacheMiss
+	}
+	return data, err
+}
+
+func (fc fileCache) Put(key string, data []byte) error {
+	path := fc.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func (fc fileCache) Keys(prefix string) ([]string, error) {
+	var keys []string
+	root := fc.path(prefix)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(fc.dir, path)
+			segments := strings.Split(filepath.ToSlash(rel), "/")
+			for i, s := range segments {
+				segments[i] = unescapeSegment(s)
+			}
+			keys = append(keys, strings.Join(segments, "/"))
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return keys, err
+}
+
+//
+// A just-enough Redis client, so that a team can share one cache without us
+// having to pull in a dependency.  Fetches are claimed with an expiring lock
+// key, so that a crashed client doesn't block everybody else forever, and a
+// client only ever releases a lock that it took itself.
+//
+
+const redisPrefix string = "ghreview:"
+
+const redisLockTimeout time.Duration = 10 * time.Minute
+
+const redisUnlockScript string = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+type redisCache struct {
+	mu    sync.Mutex
+	conn  net.Conn
+	r     *bufio.Reader
+	token string
+}
+
+func dialRedis(location string) (*redisCache, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	rc := &redisCache{conn: conn, r: bufio.NewReader(conn), token: hex.EncodeToString(buf)}
+
+	if password, ok := u.User.Password(); ok {
+		if _, err := rc.do("AUTH", password); err != nil {
+			return nil, err
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if _, err := rc.do("SELECT", db); err != nil {
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+func (rc *redisCache) Get(key string) ([]byte, error) {
+	reply, err := rc.do("GET", redisPrefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, errCacheMiss
+	}
+	return []byte(reply.(string)), nil
+}
+
+func (rc *redisCache) Put(key string, data []byte) error {
+	_, err := rc.do("SET", redisPrefix+key, string(data))
+	return err
+}
+
+func (rc *redisCache) Keys(prefix string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := rc.do("SCAN", cursor, "MATCH", redisPrefix+prefix+"*", "COUNT", "1000")
+		if err != nil {
+			return nil, err
+		}
+		parts := reply.([]any)
+		cursor = parts[0].(string)
+		for _, k := range parts[1].([]any) {
+			key := strings.TrimPrefix(k.(string), redisPrefix)
+			if !strings.HasPrefix(key, "lock:") {
+				keys = append(keys, key)
+			}
+		}
+		if cursor == "0" {
+			break
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (rc *redisCache) Lock(key string) (bool, error) {
+	ms := strconv.FormatInt(redisLockTimeout.Milliseconds(), 10)
+	reply, err := rc.do("SET", redisPrefix+"lock:"+key, rc.token, "NX", "PX", ms)
+	return reply != nil, err
+}
+
+func (rc *redisCache) Unlock(key string) error {
+	_, err := rc.do("EVAL", redisUnlockScript, "1", redisPrefix+"lock:"+key, rc.token)
+	return err
+}
+
+func (rc *redisCache) do(args ...string) (any, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := rc.conn.Write([]byte(b.String())); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+func (rc *redisCache) readReply() (any, error) {
+	line, err := rc.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New("redis: " + line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = rc.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
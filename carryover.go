@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+//
+// What was still open when the year ended is next year's starting point:
+// my PRs still waiting to land, and issues assigned to me.  "Still open"
+// means created by the end of the year and not closed by then, which the
+// search API can tell us even after the fact; who issues were assigned to,
+// it only knows as of now.
+//
+
+var openAtEnd bool
+
+func loadOpenAtEnd(repo string, year int) []Issue {
+	_, name := splitRepo(repo)
+	_, next := periodBounds(year)
+	end := next.Add(-time.Second).Format(time.RFC3339)
+
+	var queries []string
+	for _, login := range myLogins() {
+		pulls := fmt.Sprintf("repo:%s is:pr author:%s created:<=%s", name, login, end)
+		if excludeDrafts {
+			pulls += " draft:false"
+		}
+		issues := fmt.Sprintf("repo:%s is:issue assignee:%s created:<=%s", name, login, end)
+		for _, q := range []string{pulls, issues} {
+			queries = append(queries, q+" is:open", q+" closed:>"+end)
+		}
+	}
+
+	seen := map[int]bool{}
+	var open []Issue
+	for _, q := range queries {
+		for _, issue := range searchAll(repo, q) {
+			if !seen[issue.Number] && !ignored(repo, issue.Number) {
+				seen[issue.Number] = true
+				open = append(open, issue)
+			}
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].Number > open[j].Number })
+	return open
+}
+
+func (i Issue) Kind() string {
+	if i.PullRequest != nil {
+		return "PR"
+	}
+	return "issue"
+}
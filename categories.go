@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strings"
+)
+
+//
+// Categories are the user's own way of slicing the year ("reliability",
+// "features", "mentoring"), by label, title or the files a PR changes.  Each
+// contribution goes into the first category in the config that claims it,
+// so that the breakdown adds up; whatever's left is uncategorized.  Paths
+// mean fetching each PR's files, so we only do that once we get to a
+// category with paths that the PR's labels and title didn't already put it
+// in, or one before it.
+//
+
+const uncategorized string = "uncategorized"
+
+type CategoryCount struct {
+	Name    string
+	Count   int
+	Percent int
+	// Color and Slice draw the category's share of the pie chart.
+	Color string
+	Slice string
+}
+
+var categoryTitles map[string]*regexp.Regexp
+
+func categoriesEnabled() bool {
+	return len(config.Categories) > 0
+}
+
+func compileCategories() {
+	if categoryTitles != nil {
+		return
+	}
+	categoryTitles = map[string]*regexp.Regexp{}
+	for _, c := range config.Categories {
+		if c.Title == "" {
+			continue
+		}
+		re, err := regexp.Compile(c.Title)
+		if err != nil {
+			log.Fatalf("bad title pattern for category %s: %s", c.Name, err)
+		}
+		categoryTitles[c.Name] = re
+	}
+}
+
+func categorize(repo string, pulls []Pull) {
+	if !categoriesEnabled() {
+		return
+	}
+	compileCategories()
+	for i := range pulls {
+		pulls[i].Category = categoryOf(repo, pulls[i])
+	}
+}
+
+func categoryOf(repo string, p Pull) string {
+	var files []string
+	haveFiles := false
+	for _, c := range config.Categories {
+		for _, label := range p.Labels {
+			for _, want := range c.Labels {
+				if strings.EqualFold(label.Name, want) {
+					return c.Name
+				}
+			}
+		}
+		if re := categoryTitles[c.Name]; re != nil && re.MatchString(p.Title) {
+			return c.Name
+		}
+		if len(c.Paths) == 0 {
+			continue
+		}
+		if !haveFiles {
+			files, haveFiles = loadFiles(repo, p), true
+		}
+		for _, name := range files {
+			for _, glob := range c.Paths {
+				if matchGlob(glob, name) {
+					return c.Name
+				}
+			}
+		}
+	}
+	return uncategorized
+}
+
+// categoryBreakdown counts contributions by category, in the order the
+// config lists them, with the uncategorized ones last.  Each category's
+// color comes from its place in the config too, so it's the same from one
+// report to the next.
+func categoryBreakdown(results []RepoResult) []CategoryCount {
+	counts := map[string]int{}
+	total := 0
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			counts[p.Category]++
+			total++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, c := range config.Categories {
+		names = append(names, c.Name)
+	}
+	names = append(names, uncategorized)
+
+	var breakdown []CategoryCount
+	start := 0.0
+	for i, name := range names {
+		if counts[name] == 0 {
+			continue
+		}
+		share := float64(counts[name]) / float64(total)
+		breakdown = append(breakdown, CategoryCount{
+			Name:    name,
+			Count:   counts[name],
+			Percent: 100 * counts[name] / total,
+			Color:   fmt.Sprintf("hsl(%d, 60%%, 55%%)", i*360/len(names)),
+			Slice:   pieSlice(start, start+share),
+		})
+		start += share
+	}
+	return breakdown
+}
+
+// pieSlice is an SVG path for the part of a pie of radius 50, centered at
+// (60, 60), between two fractions of the way round.
+func pieSlice(from, to float64) string {
+	point := func(f float64) (float64, float64) {
+		angle := 2*math.Pi*f - math.Pi/2
+		return 60 + 50*math.Cos(angle), 60 + 50*math.Sin(angle)
+	}
+	if to-from > 0.9999 {
+		return "M60,10 A50,50 0 1 1 60,110 A50,50 0 1 1 60,10 Z"
+	}
+	x0, y0 := point(from)
+	x1, y1 := point(to)
+	large := 0
+	if to-from > 0.5 {
+		large = 1
+	}
+	return fmt.Sprintf("M60,60 L%.2f,%.2f A50,50 0 %d 1 %.2f,%.2f Z", x0, y0, large, x1, y1)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//
+// Which columns the PR tables have is up to the user.  Both renderers ask
+// showColumn about each one, so they always agree.
+//
+
+const defaultColumns string = "number,timestamp,state,contribution,title"
+
+var allColumns = []string{"number", "timestamp", "state", "contribution", "title", "labels", "milestone", "assignees", "comments", "size", "category"}
+
+var columns string = defaultColumns
+
+func showColumn(name string) bool {
+	for _, c := range strings.Split(columns, ",") {
+		if strings.TrimSpace(c) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func checkColumns() {
+	for _, c := range strings.Split(columns, ",") {
+		c = strings.TrimSpace(c)
+		known := false
+		for _, a := range allColumns {
+			known = known || a == c
+		}
+		if !known {
+			log.Fatalf("unknown column %q, expected some of %s", c, strings.Join(allColumns, ","))
+		}
+	}
+}
+
+// loadDetails fills in what only the individual PR endpoint knows.
+func loadDetails(repo string, pulls []Pull) {
+	for i := range pulls {
+		full := loadPull(repo, pulls[i].Number)
+		pulls[i].Comments = full.Comments
+		pulls[i].ReviewComments = full.ReviewComments
+		pulls[i].Additions = full.Additions
+		pulls[i].Deletions = full.Deletions
+	}
+}
+
+func (p Pull) LabelNames() string {
+	var names []string
+	for _, l := range p.Labels {
+		names = append(names, l.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (p Pull) AssigneeLogins() string {
+	var logins []string
+	for _, a := range p.Assignees {
+		logins = append(logins, a.Login)
+	}
+	return strings.Join(logins, ", ")
+}
+
+func (p Pull) CommentCount() int {
+	return p.Comments + p.ReviewComments
+}
+
+func (p Pull) Size() string {
+	return fmt.Sprintf("+%d −%d", p.Additions, p.Deletions)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//
+// Committing the report to a repo on every run gives us a versioned history
+// of it for free.  We go through the contents API, so there's no clone and
+// no git involved on our side.
+//
+
+type contentsFile struct {
+	Sha     string
+	Content string
+}
+
+func commitReport(target string, results []RepoResult, year int) {
+	repo, path, ok := strings.Cut(target, ":")
+	if !ok || path == "" {
+		log.Fatalf("--commit-to %s: expected owner/repo:path", target)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if strings.HasSuffix(path, ".md") {
+		err = renderMarkdown(&buf, results, year)
+	} else {
+		err = renderHTML(&buf, results, year)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	url := repoURL(repo, "/contents/%s", strings.TrimPrefix(path, "/"))
+	body := map[string]any{
+		"message": fmt.Sprintf("Update the contributions report for %s in %s", user, periodName(year)),
+		"content": base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}
+
+	var existing contentsFile
+	if apiLookup(url, &existing) {
+		//
+		// The API wraps the base64 over several lines.
+		//
+		old, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(existing.Content, "\n", ""))
+		if err == nil && bytes.Equal(old, buf.Bytes()) {
+			log.Printf("%s is already up to date", target)
+			return
+		}
+		body["sha"] = existing.Sha
+	}
+
+	apiRequest(http.MethodPut, url, body, nil)
+	log.Printf("committed the report to %s", target)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// In squash-merge repos, what lands on the main branch is one commit per PR,
+// and the PR might not be one we authored (e.g. we pushed fixes to somebody
+// else's branch).  Here we go from our commits back to the PRs they came
+// from, so that that work shows up in the report too.
+//
+
+type Commit struct {
+	Sha     string
+	HtmlUrl string `json:"html_url"`
+	Commit  struct {
+		Message string
+		Author  struct {
+			Date string
+		}
+		Verification Verification
+	}
+}
+
+// Squash merges append the PR number to the subject, e.g. "Fix foo (#1234)".
+var squashSuffix = regexp.MustCompile(`\(#(\d+)\)$`)
+
+func loadCommits(repo string, author string, year int, page int) []Commit {
+	var commits []Commit
+	first, next := periodBounds(year)
+	since, until := first.Format(time.RFC3339), next.Format(time.RFC3339)
+	fetch(repoURL(repo, "/commits?author=%s&since=%s&until=%s&page=%d", url.QueryEscape(author), since, until, page), &commits)
+	return commits
+}
+
+func loadCommitPulls(repo string, sha string) []Pull {
+	var pulls []Pull
+	fetch(repoURL(repo, "/commits/%s/pulls", sha), &pulls)
+	return pulls
+}
+
+// pullForCommit works out which PR a commit landed through, or returns 0 if
+// it was pushed directly.
+func pullForCommit(repo string, c Commit) int {
+	subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+	if m := squashSuffix.FindStringSubmatch(strings.TrimSpace(subject)); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		return number
+	}
+	for _, p := range loadCommitPulls(repo, c.Sha) {
+		if p.MergedAt != "" {
+			return p.Number
+		}
+	}
+	return 0
+}
+
+func mapCommitsToPulls(repo string, year int, result *RepoResult) {
+	seen := map[int]bool{}
+	for _, p := range result.Pulls {
+		seen[p.Number] = true
+	}
+
+	//
+	// The API matches authors by login or by email, and we may have several
+	// of each, so the same commit can come back more than once.
+	//
+	shas := map[string]bool{}
+	for _, author := range myAuthors() {
+		for page := 1; ; page++ {
+			commits := loadCommits(repo, author, year, page)
+			if len(commits) == 0 {
+				break
+			}
+			for _, c := range commits {
+				if !shas[c.Sha] {
+					shas[c.Sha] = true
+					attributeCommit(repo, c, seen, result)
+				}
+			}
+		}
+	}
+	sort.Sort(PullList(result.Pulls))
+}
+
+func attributeCommit(repo string, c Commit, seen map[int]bool, result *RepoResult) {
+	number := pullForCommit(repo, c)
+	if number == 0 {
+		result.Direct++
+		return
+	}
+	if seen[number] || ignored(repo, number) {
+		return
+	}
+	seen[number] = true
+
+	p := loadPull(repo, number)
+	p.MyContribution = "committed"
+	p.Timestamp = c.Commit.Author.Date
+	result.add(p)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+//
+// Things that don't fit comfortably on the command line live in a JSON
+// config file.  Everything in there is optional.
+//
+
+type HostConfig struct {
+	// API overrides the API base URL, e.g. https://github.example.com/api/v3
+	API string
+	// Token is used as is; TokenEnv names an environment variable to read the
+	// token from, which keeps secrets out of the file.
+	Token    string
+	TokenEnv string
+}
+
+// Identity lets one person go by several logins (e.g. after a rename) and
+// commit under several emails.
+type Identity struct {
+	Logins []string
+	Emails []string
+}
+
+// MatrixConfig says which room to post completion notifications to.
+type MatrixConfig struct {
+	Homeserver string
+	Room       string
+	Token      string
+	TokenEnv   string
+}
+
+type NotifyConfig struct {
+	// Webhooks get a JSON summary POSTed to them at the end of each run.
+	Webhooks []string
+	Matrix   *MatrixConfig
+	// Commands are run by the shell at the end of each run, with the report
+	// and the summary in the environment; see hooks.go.
+	Commands []string
+}
+
+type JiraConfig struct {
+	// URL is where Jira lives, e.g. https://example.atlassian.net
+	URL string
+	// Projects limits which keys we recognize, which cuts down on false
+	// positives like UTF-8; if empty, anything that looks like a key goes.
+	Projects []string
+	// GroupByEpic adds a section listing PRs by the epic of their tickets,
+	// which means asking Jira about each ticket.  User and Token (or
+	// TokenEnv) are for that; without a User, the token is sent as a bearer
+	// token.
+	GroupByEpic bool
+	User        string
+	Token       string
+	TokenEnv    string
+	// EpicField is the custom field holding the epic link on older Jira
+	// setups, e.g. customfield_10014; otherwise we use the parent.
+	EpicField string
+}
+
+// TrackerConfig recognizes references to some other issue tracker, e.g.
+// Linear or Shortcut.  URL may refer to the match as $0 and to the pattern's
+// groups as $1, $2 and so on.
+type TrackerConfig struct {
+	Name    string
+	Pattern string
+	URL     string
+	// Group adds a section listing PRs by ticket.
+	Group bool
+}
+
+// CategoryConfig claims PRs that have any of Labels, whose title matches
+// the Title regexp, or that change files matching any of Paths (globs, with
+// ** for any number of directories).
+type CategoryConfig struct {
+	Name   string
+	Labels []string
+	Title  string
+	Paths  []string
+}
+
+type Config struct {
+	Hosts      map[string]HostConfig
+	Identities map[string]Identity
+	// Groups maps a group name to the repos in it; owner/* style patterns
+	// work too.
+	Groups map[string][]string
+	// Weights gives each kind of contribution (authored, merged, ...) a
+	// weight for computing activity scores.
+	Weights map[string]float64
+	// Targets are yearly goals for each kind of contribution, e.g. merged=50.
+	Targets map[string]int
+	Notify  NotifyConfig
+	Jira    *JiraConfig
+	// Trackers, for example
+	//
+	//	{"Name": "Linear", "Pattern": "ENG-[0-9]+", "URL": "https://linear.app/acme/issue/$0"}
+	//	{"Name": "Shortcut", "Pattern": "(?i)sc-([0-9]+)", "URL": "https://app.shortcut.com/acme/story/$1"}
+	Trackers []TrackerConfig
+	// TimeZone is an IANA zone name like Europe/Berlin, or Local; timestamps
+	// are shown, and years and months counted, in that zone.  The default is
+	// UTC.  TimeFormat is a Go time layout, 2006-01-02 by default.
+	TimeZone   string
+	TimeFormat string
+	// Categories are tried in order, e.g.
+	//
+	//	{"Name": "reliability", "Labels": ["incident"], "Title": "(?i)flak|outage"}
+	//	{"Name": "docs", "Paths": ["docs/**"]}
+	Categories []CategoryConfig
+	// DependencyBots are logins whose PRs are dependency updates, on top of
+	// Dependabot, Renovate and the like.
+	DependencyBots []string
+	// Rules add kinds of contribution after the built-in ones; see rules.go.
+	Rules []RuleConfig
+	// FiscalYearStart is the month the fiscal year starts in, for --preset;
+	// see period.go.
+	FiscalYearStart int
+	// Blackouts are time away; see blackout.go.
+	Blackouts []BlackoutConfig
+	// WorkingHours are when contributions don't count as after hours; see
+	// afterhours.go.
+	WorkingHours *WorkingHoursConfig
+	// Colors overrides the report's colors for states and contributions;
+	// see palette.go.
+	Colors map[string]string
+}
+
+var config Config
+
+var configPath string
+
+func loadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && (path == localConfigPath || path == userConfigPath()) {
+		return c, nil
+	} else if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import "strings"
+
+//
+// Merging bot PRs that bump dependencies is real work, but dozens of rows
+// of "Bump foo from 1.2.3 to 1.2.4" drown out everything else.  So the
+// tables roll up the dependency updates I merged into one row per repo,
+// unless --expand-deps asks for them one by one.  They count the same
+// either way.
+//
+
+var dependencyBots = []string{"dependabot[bot]", "dependabot-preview[bot]", "renovate[bot]", "renovate-bot", "depfu[bot]", "pyup-bot", "snyk-bot"}
+
+var expandDeps bool
+
+func isDependencyBot(login string) bool {
+	for _, bot := range append(dependencyBots, config.DependencyBots...) {
+		if strings.EqualFold(bot, login) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Pull) IsDependencyUpdate() bool {
+	return p.MyContribution == "merged" && isDependencyBot(p.User.Login)
+}
+
+// TablePulls are the PRs that get a row of their own.
+func (result RepoResult) TablePulls() []Pull {
+	if expandDeps {
+		return result.Pulls
+	}
+	var pulls []Pull
+	for _, p := range result.Pulls {
+		if !p.IsDependencyUpdate() {
+			pulls = append(pulls, p)
+		}
+	}
+	return pulls
+}
+
+// DependencyUpdates counts the PRs rolled up into one row.
+func (result RepoResult) DependencyUpdates() int {
+	if expandDeps {
+		return 0
+	}
+	n := 0
+	for _, p := range result.Pulls {
+		if p.IsDependencyUpdate() {
+			n++
+		}
+	}
+	return n
+}
+
+// columnCount is how many columns the PR tables have.
+func columnCount() int {
+	n := 0
+	for _, c := range allColumns {
+		if showColumn(c) {
+			n++
+		}
+	}
+	if ticketsEnabled() {
+		n++
+	}
+	if notesEnabled() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+)
+
+//
+// Counting PRs says nothing about how closely anybody looked at them.  With
+// --review-depth, each repo also says how many comments my authored PRs got
+// from other people, and how many I left on other people's PRs that I
+// reviewed, inline or in the conversation.  Only PRs in the report count,
+// so to include the ones I only reviewed, use --reviews too.
+//
+
+// Comment pages stop at 100, and 10 pages is more than any sane thread.
+const (
+	commentsPageSize int = 100
+	commentsMaxPages int = 10
+)
+
+var reviewDepth bool
+
+type Comment struct {
+	User      User
+	CreatedAt string `json:"created_at"`
+}
+
+type ReviewDepth struct {
+	Received int
+	Authored int
+	Given    int
+	Reviews  int
+}
+
+func (d ReviewDepth) AverageReceived() string {
+	return average(d.Received, d.Authored)
+}
+
+func (d ReviewDepth) AverageGiven() string {
+	return average(d.Given, d.Reviews)
+}
+
+func average(total int, count int) string {
+	if count == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.1f", float64(total)/float64(count))
+}
+
+// loadComments is everybody's comments on the PR: inline ones, and those in
+// the conversation.
+func loadComments(repo string, pull Pull) []Comment {
+	var all []Comment
+	for _, endpoint := range []string{"/pulls/%d/comments", "/issues/%d/comments"} {
+		for page := 1; page <= commentsMaxPages; page++ {
+			var comments []Comment
+			fetch(repoURL(repo, endpoint+"?per_page=%d&page=%d", pull.Number, commentsPageSize, page), &comments)
+			all = append(all, comments...)
+			if len(comments) < commentsPageSize {
+				break
+			}
+		}
+	}
+	return all
+}
+
+func loadReviewDepth(repo string, pulls []Pull) *ReviewDepth {
+	var depth ReviewDepth
+	for _, p := range pulls {
+		authored := p.MyContribution == "authored"
+		if !authored && !iReviewed(repo, p) {
+			continue
+		}
+		mine, others := 0, 0
+		for _, c := range loadComments(repo, p) {
+			if isMe(c.User.Login) {
+				mine++
+			} else {
+				others++
+			}
+		}
+		if authored {
+			depth.Authored++
+			depth.Received += others
+		} else {
+			depth.Reviews++
+			depth.Given += mine
+		}
+	}
+	return &depth
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+//
+// The cache and config live where the platform keeps such things, e.g.
+// $XDG_CACHE_HOME/ghreview and $XDG_CONFIG_HOME/ghreview/ghreview.json.
+// A ./cache or ./ghreview.json in the working directory wins, which is how
+// older versions kept them, and --local puts them there on purpose, so that
+// a report pipeline can check in its cache and re-render reproducibly.
+//
+
+const localCacheDir string = "cache"
+
+const localConfigPath string = "ghreview.json"
+
+var useLocal bool
+
+func defaultCacheDir() string {
+	if info, err := os.Stat(localCacheDir); err == nil && info.IsDir() {
+		return localCacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return localCacheDir
+	}
+	return filepath.Join(dir, "ghreview")
+}
+
+func defaultConfigPath() string {
+	if _, err := os.Stat(localConfigPath); err == nil {
+		return localConfigPath
+	}
+	return userConfigPath()
+}
+
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return localConfigPath
+	}
+	return filepath.Join(dir, "ghreview", localConfigPath)
+}
+
+// applyLocal points whichever of --cache and --config weren't given at the
+// working directory.
+func applyLocal(flags *flag.FlagSet) {
+	if !useLocal {
+		return
+	}
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if !given["cache"] {
+		cacheURL = localCacheDir
+	}
+	if !given["config"] {
+		configPath = localConfigPath
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//
+// The HTML report carries the data it was rendered from, as JSON, so that
+// the one file can be re-sorted, filtered and downloaded as CSV in the
+// browser without going back to us.  The controls are added by the script,
+// so they don't show up where scripts don't run.  (encoding/json escapes <,
+// > and &, so the data can't end the script element early.)
+//
+
+const dataScript string = `<script>
+(function () {
+    var data = JSON.parse(document.getElementById("ghreview-data").textContent);
+    var filter = document.createElement("input");
+    filter.type = "search";
+    filter.placeholder = "Filter PRs";
+    filter.setAttribute("aria-label", "Filter PRs");
+    var csv = document.createElement("button");
+    csv.textContent = "Download CSV";
+    var bar = document.createElement("div");
+    bar.className = "toolbar";
+    bar.setAttribute("role", "search");
+    bar.append(filter, " ", csv);
+    document.body.prepend(bar);
+
+    filter.addEventListener("input", function () {
+        var q = filter.value.toLowerCase();
+        document.querySelectorAll("table.pulls tbody tr").forEach(function (tr) {
+            tr.hidden = q !== "" && tr.textContent.toLowerCase().indexOf(q) < 0;
+        });
+    });
+
+    document.querySelectorAll("table.pulls thead th").forEach(function (th) {
+        th.style.cursor = "pointer";
+        th.addEventListener("click", function () {
+            var tbody = th.closest("table").tBodies[0];
+            var i = Array.prototype.indexOf.call(th.parentNode.children, th);
+            var asc = th.dataset.sorted !== "asc";
+            th.dataset.sorted = asc ? "asc" : "desc";
+            var rows = Array.from(tbody.rows);
+            rows.sort(function (a, b) {
+                if (a.cells.length === 1 || b.cells.length === 1) {
+                    return a.cells.length === 1 ? 1 : -1;
+                }
+                var x = a.cells[i].textContent.trim(), y = b.cells[i].textContent.trim();
+                var c = (isNaN(x) || isNaN(y) || x === "" || y === "") ? x.localeCompare(y) : x - y;
+                return asc ? c : -c;
+            });
+            rows.forEach(function (tr) { tbody.appendChild(tr); });
+        });
+    });
+
+    csv.addEventListener("click", function () {
+        var q = filter.value.toLowerCase();
+        var lines = [["repo", "number", "timestamp", "state", "contribution", "title", "url"]];
+        data.forEach(function (r) {
+            (r.Pulls || []).forEach(function (p) {
+                var line = [r.Name, p.Number, p.Timestamp, p.State, p.MyContribution, p.Title, p.html_url];
+                if (q === "" || line.join(" ").toLowerCase().indexOf(q) >= 0) {
+                    lines.push(line);
+                }
+            });
+        });
+        var text = lines.map(function (line) {
+            return line.map(function (v) {
+                return '"' + String(v === null || v === undefined ? "" : v).replace(/"/g, '""') + '"';
+            }).join(",");
+        }).join("\r\n");
+        var a = document.createElement("a");
+        a.href = URL.createObjectURL(new Blob([text], {type: "text/csv"}));
+        a.download = "ghreview.csv";
+        a.click();
+    });
+})();
+</script>`
+
+func writeData(w io.Writer, results []RepoResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "<script type=\"application/json\" id=\"ghreview-data\">%s</script>\n%s\n", data, dataScript)
+	return err
+}
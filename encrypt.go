@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+//
+// Cached responses from private repos are as sensitive as the repos, so with
+// --encrypt we seal every cache entry with AES-GCM before it hits the disk
+// (or Redis).  The key comes from $GHREVIEW_CACHE_KEY, or failing that from
+// the OS keyring, where you'd put it with e.g.
+//
+//	secret-tool store --label ghreview service ghreview account cache
+//	security add-generic-password -s ghreview -a cache -w
+//
+// Any long random string will do, e.g. the output of openssl rand -hex 32.
+// Each entry's key is bound into its seal, so entries can't be swapped
+// around either.  Keys themselves, i.e. which URLs we fetched, are not
+// hidden.
+//
+
+const cacheKeyEnv string = "GHREVIEW_CACHE_KEY"
+
+const sealedMagic string = "ghreview-aes-gcm\n"
+
+var encryptCache bool
+
+type encryptedCache struct {
+	Cache
+	aead cipher.AEAD
+}
+
+func encrypt(c Cache) Cache {
+	secret := cacheSecret()
+	if secret == "" {
+		log.Fatalf("--encrypt needs a key in $%s or in the OS keyring (service ghreview, account cache)", cacheKeyEnv)
+	}
+	sum := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return encryptedCache{c, aead}
+}
+
+func cacheSecret() string {
+	if secret := os.Getenv(cacheKeyEnv); secret != "" {
+		return secret
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", "ghreview", "-a", "cache", "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", "ghreview", "account", "cache")
+	default:
+		return ""
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// backend is the cache that actually stores things, under any encryption.
+func backend() Cache {
+	if ec, ok := cache.(encryptedCache); ok {
+		return ec.Cache
+	}
+	return cache
+}
+
+func isSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sealedMagic))
+}
+
+// Get treats entries that were stored in the clear as missing, so that they
+// get refetched and stored sealed.
+func (ec encryptedCache) Get(key string) ([]byte, error) {
+	data, err := ec.Cache.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !isSealed(data) {
+		return nil, errCacheMiss
+	}
+	data = data[len(sealedMagic):]
+	size := ec.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.New("truncated cache entry " + key)
+	}
+	plain, err := ec.aead.Open(nil, data[:size], data[size:], []byte(key))
+	if err != nil {
+		return nil, errors.New("unable to decrypt cache entry " + key + ", is it the right key?")
+	}
+	return plain, nil
+}
+
+func (ec encryptedCache) Put(key string, data []byte) error {
+	nonce := make([]byte, ec.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := append([]byte(sealedMagic), nonce...)
+	sealed = ec.aead.Seal(sealed, nonce, data, []byte(key))
+	return ec.Cache.Put(key, sealed)
+}
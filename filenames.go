@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+//
+// Cache keys come from URLs, so they can have characters in them that
+// Windows won't have in a file name (the colon in host:port, to begin with),
+// spell one of its reserved device names, or run longer than a file name may
+// be.  The file cache percent-escapes anything outside a conservative set of
+// characters (the percent sign among them, so that an escaped name never
+// collides with a literal one), and hashes the tail of overlong names, one
+// path segment at a time.  Keys read back from the directory (see Keys) are
+// unescaped again, so that they find their files.
+//
+
+const maxSegment int = 120
+
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func (fc fileCache) path(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = safeSegment(s)
+	}
+	return filepath.Join(fc.dir, filepath.FromSlash(strings.Join(segments, "/")))
+}
+
+func safeSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if safeByte(c) && !(c == '.' && i == len(s)-1) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	safe := b.String()
+
+	base, _, _ := strings.Cut(safe, ".")
+	if reservedNames[strings.ToUpper(base)] {
+		safe = fmt.Sprintf("%%%02X", safe[0]) + safe[1:]
+	}
+	if len(safe) > maxSegment {
+		sum := sha256.Sum256([]byte(safe))
+		ext := filepath.Ext(safe)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		cut := maxSegment - len(ext) - 17
+		//
+		// Don't cut an escape in half, so that the name still unescapes.
+		//
+		if i := strings.LastIndexByte(safe[:cut], '%'); i >= cut-2 {
+			cut = i
+		}
+		safe = safe[:cut] + "~" + hex.EncodeToString(sum[:8]) + ext
+	}
+	return safe
+}
+
+func safeByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~=&+,@!$'()", c) >= 0
+}
+
+// unescapeSegment turns a file name back into the part of the key it came
+// from.  Overlong names stay hashed, but those escape to themselves.
+func unescapeSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if c, err := hex.DecodeString(s[i+1 : i+3]); err == nil {
+				b.Write(c)
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+//
+// PR titles are written for Github, which renders :emoji: shortcodes and
+// `inline code` in them.  With --gfm-titles, the HTML report does the same,
+// instead of showing them literally.  We only know the shortcodes that
+// people actually put in titles (gitmoji and friends); the rest stay as
+// they are.
+//
+
+var gfmTitles bool
+
+var emoji = map[string]string{
+	"+1":                        "\U0001F44D",
+	"-1":                        "\U0001F44E",
+	"alembic":                   "⚗️",
+	"ambulance":                 "\U0001F691",
+	"apple":                     "\U0001F34E",
+	"arrow_down":                "⬇️",
+	"arrow_up":                  "⬆️",
+	"art":                       "\U0001F3A8",
+	"beers":                     "\U0001F37B",
+	"bento":                     "\U0001F371",
+	"bookmark":                  "\U0001F516",
+	"books":                     "\U0001F4DA",
+	"boom":                      "\U0001F4A5",
+	"bug":                       "\U0001F41B",
+	"building_construction":     "\U0001F3D7️",
+	"bulb":                      "\U0001F4A1",
+	"busts_in_silhouette":       "\U0001F465",
+	"card_file_box":             "\U0001F5C3️",
+	"chart_with_upwards_trend":  "\U0001F4C8",
+	"children_crossing":         "\U0001F6B8",
+	"clown_face":                "\U0001F921",
+	"construction":              "\U0001F6A7",
+	"construction_worker":       "\U0001F477",
+	"fire":                      "\U0001F525",
+	"globe_with_meridians":      "\U0001F310",
+	"green_heart":               "\U0001F49A",
+	"hammer":                    "\U0001F528",
+	"heavy_check_mark":          "✔️",
+	"heavy_minus_sign":          "➖",
+	"heavy_plus_sign":           "➕",
+	"iphone":                    "\U0001F4F1",
+	"label":                     "\U0001F3F7️",
+	"lipstick":                  "\U0001F484",
+	"lock":                      "\U0001F512",
+	"loud_sound":                "\U0001F50A",
+	"mag":                       "\U0001F50D",
+	"memo":                      "\U0001F4DD",
+	"mute":                      "\U0001F507",
+	"package":                   "\U0001F4E6",
+	"pencil":                    "\U0001F4DD",
+	"pencil2":                   "✏️",
+	"poop":                      "\U0001F4A9",
+	"pushpin":                   "\U0001F4CC",
+	"recycle":                   "♻️",
+	"rewind":                    "⏪",
+	"rocket":                    "\U0001F680",
+	"rotating_light":            "\U0001F6A8",
+	"see_no_evil":               "\U0001F648",
+	"seedling":                  "\U0001F331",
+	"sparkles":                  "✨",
+	"speech_balloon":            "\U0001F4AC",
+	"tada":                      "\U0001F389",
+	"truck":                     "\U0001F69A",
+	"twisted_rightwards_arrows": "\U0001F500",
+	"wastebasket":               "\U0001F5D1️",
+	"wheelchair":                "♿",
+	"white_check_mark":          "✅",
+	"wrench":                    "\U0001F527",
+	"x":                         "❌",
+	"zap":                       "⚡",
+}
+
+var (
+	shortcodePattern  = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+	inlineCodePattern = regexp.MustCompile("`([^`]+)`")
+)
+
+// renderTitle is how a title goes into the HTML report.  Everything is
+// escaped first, so the only markup in the result is ours.
+func renderTitle(title string) template.HTML {
+	escaped := template.HTMLEscapeString(title)
+	if !gfmTitles {
+		return template.HTML(escaped)
+	}
+
+	//
+	// Shortcodes inside code spans stay literal, like on Github, so we
+	// split the title on the code spans and only replace in between.
+	//
+	var b strings.Builder
+	last := 0
+	for _, span := range inlineCodePattern.FindAllStringSubmatchIndex(escaped, -1) {
+		b.WriteString(replaceShortcodes(escaped[last:span[0]]))
+		b.WriteString("<code>" + escaped[span[2]:span[3]] + "</code>")
+		last = span[1]
+	}
+	b.WriteString(replaceShortcodes(escaped[last:]))
+	return template.HTML(b.String())
+}
+
+func replaceShortcodes(s string) string {
+	return shortcodePattern.ReplaceAllStringFunc(s, func(code string) string {
+		if e, ok := emoji[strings.Trim(code, ":")]; ok {
+			return e
+		}
+		return code
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+//
+// For some people, snippets and write-ups are a good part of the year's
+// output, and those live in gists rather than repos.  With --gists, we list
+// the user's public gists on the host of the first repo, and add the ones
+// created or last updated during the period.  Github only remembers when a
+// gist was last updated, so one that was updated during the period and
+// again since doesn't count, unless it was created during the period too.
+//
+
+var gistActivity bool
+
+type GistFile struct {
+	Filename string
+}
+
+type Gist struct {
+	HtmlUrl     string `json:"html_url"`
+	Description string
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	Files       map[string]GistFile
+	// Action is whether I created or updated it during the period.
+	Action string `json:"-"`
+}
+
+type GistWork struct {
+	Created int
+	Updated int
+	Gists   []Gist
+}
+
+// Title is the gist's description, or its first file if it has none.
+func (g Gist) Title() string {
+	if g.Description != "" {
+		return g.Description
+	}
+	var names []string
+	for name := range g.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "untitled"
+	}
+	return names[0]
+}
+
+// When is when I created it, or last updated it.
+func (g Gist) When() string {
+	if g.Action == "created" {
+		return g.CreatedAt
+	}
+	return g.UpdatedAt
+}
+
+func loadGists(results []RepoResult, year int) *GistWork {
+	host := hostFor(defaultHost)
+	if len(results) > 0 {
+		host, _ = splitRepo(results[0].Name)
+	}
+	first, _ := periodBounds(year)
+
+	work := &GistWork{}
+	seen := map[string]bool{}
+	for _, login := range myLogins() {
+		for page := 1; ; page++ {
+			var gists []Gist
+			u := fmt.Sprintf("%s/users/%s/gists?since=%s&per_page=100&page=%d", host.API, url.PathEscape(login), first.Format(time.RFC3339), page)
+			if !fetchOptional(u, &gists) {
+				break
+			}
+			for _, g := range gists {
+				if seen[g.HtmlUrl] {
+					continue
+				}
+				seen[g.HtmlUrl] = true
+				if created, err := parseTimestamp(g.CreatedAt); err == nil && inPeriod(created, year) {
+					g.Action = "created"
+					work.Created++
+				} else if updated, err := parseTimestamp(g.UpdatedAt); err == nil && inPeriod(updated, year) {
+					g.Action = "updated"
+					work.Updated++
+				} else {
+					continue
+				}
+				work.Gists = append(work.Gists, g)
+			}
+			if len(gists) < 100 {
+				break
+			}
+		}
+	}
+	sort.Slice(work.Gists, func(i, j int) bool { return work.Gists[i].When() > work.Gists[j].When() })
+	return work
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Commit-level numbers (lines, files, commits) are expensive to get out of
+// the API, one diff per PR, but a local clone has all of them for free.  We
+// shell out to git rather than linking a git implementation in.
+//
+
+type AuthorStats struct {
+	Author  string
+	Commits int
+	Files   int
+	Added   int
+	Removed int
+	// AvatarUrl is the author's picture on the host, with --profile.
+	AvatarUrl string
+
+	email string
+	files map[string]bool
+}
+
+// parseGitDirs matches --git-dir values up with repos.  A bare path is only
+// unambiguous when we're reporting on a single repo.
+func parseGitDirs(gitDirs []string, repos []string) map[string]string {
+	clones := map[string]string{}
+	for _, value := range gitDirs {
+		if repo, dir, ok := strings.Cut(value, "="); ok {
+			clones[repo] = dir
+		} else if len(repos) == 1 {
+			clones[repos[0]] = value
+		} else {
+			log.Fatalf("--git-dir %s: say which repo it is, e.g. --git-dir owner/repo=%s", value, value)
+		}
+	}
+	return clones
+}
+
+func gitStats(dir string, year int) []AuthorStats {
+	//
+	// git filters --since on the commit date, which is never earlier than
+	// the author date, so this only narrows things down; we check the author
+	// date ourselves below.
+	//
+	start, _ := periodBounds(year)
+	since := start.Format(time.RFC3339)
+	cmd := exec.Command("git", "-C", dir, "log", "--no-merges", "--numstat", "--since="+since, "--format=%x00%aN%x00%aI%x00%aE")
+	out, err := cmd.Output()
+	if err != nil {
+		log.Fatalf("unable to read the git log in %s: %s", dir, err)
+	}
+
+	byAuthor := map[string]*AuthorStats{}
+	var current *AuthorStats
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			fields := strings.Split(line, "\x00")
+			current = nil
+			authored, err := time.Parse(time.RFC3339, fields[2])
+			if err != nil || !inPeriod(authored, year) {
+				continue
+			}
+			author := personForEmail(fields[3], fields[1])
+			if byAuthor[author] == nil {
+				byAuthor[author] = &AuthorStats{Author: author, email: fields[3], files: map[string]bool{}}
+			}
+			current = byAuthor[author]
+			current.Commits++
+			continue
+		}
+
+		fields := strings.SplitN(line, "\t", 3)
+		if current == nil || len(fields) != 3 {
+			continue
+		}
+		//
+		// Binary files show up as "-" instead of a line count.
+		//
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		current.Added += added
+		current.Removed += removed
+		current.files[fields[2]] = true
+	}
+
+	var stats []AuthorStats
+	for _, a := range byAuthor {
+		a.Files = len(a.files)
+		stats = append(stats, *a)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Commits != stats[j].Commits {
+			return stats[i].Commits > stats[j].Commits
+		}
+		return stats[i].Author < stats[j].Author
+	})
+	return stats
+}
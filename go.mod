@@ -0,0 +1,3 @@
+module github.com/mpenkov/ghreview
+
+go 1.22
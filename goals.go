@@ -0,0 +1,35 @@
+package main
+
+import "sort"
+
+//
+// Targets from the config turn the report into a lightweight goal tracker:
+// how far along are we towards, say, 50 merged PRs this year?
+//
+
+type Goal struct {
+	Name    string
+	Done    int
+	Target  int
+	Percent int
+}
+
+func goals(results []RepoResult) []Goal {
+	done := map[string]int{}
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			done[p.MyContribution]++
+		}
+	}
+
+	var goals []Goal
+	for name, target := range config.Targets {
+		g := Goal{Name: name, Done: done[name], Target: target}
+		if target > 0 {
+			g.Percent = 100 * g.Done / target
+		}
+		goals = append(goals, g)
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].Name < goals[j].Name })
+	return goals
+}
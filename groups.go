@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path"
+	"sort"
+)
+
+//
+// Groups let one report be split into sections like "work" and "oss", with
+// subtotals for each.  Repos that don't belong to any group end up in a
+// catch-all group at the end.
+//
+
+const otherGroup string = "other"
+
+type Group struct {
+	Name     string
+	Repos    []RepoResult
+	Authored int
+	Merged   int
+	Reviewed int
+	Score    float64
+}
+
+// groupFor returns the first group, in alphabetical order, that repo
+// belongs to.
+func groupFor(repo string) string {
+	var names []string
+	for name := range config.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, pattern := range config.Groups[name] {
+			if matched, _ := path.Match(pattern, repo); matched {
+				return name
+			}
+		}
+	}
+	return otherGroup
+}
+
+func groupResults(results []RepoResult) []Group {
+	byName := map[string]*Group{}
+	var names []string
+	for _, result := range results {
+		name := groupFor(result.Name)
+		g, ok := byName[name]
+		if !ok {
+			g = &Group{Name: name}
+			byName[name] = g
+			names = append(names, name)
+		}
+		g.Repos = append(g.Repos, result)
+		g.Authored += result.Authored
+		g.Merged += result.Merged
+		g.Reviewed += result.Reviewed
+		g.Score += result.Score
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == otherGroup) != (names[j] == otherGroup) {
+			return names[j] == otherGroup
+		}
+		return names[i] < names[j]
+	})
+
+	var groups []Group
+	for _, name := range names {
+		groups = append(groups, *byName[name])
+	}
+	return groups
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+//
+// Webhooks and Matrix don't cover everything people want done with a
+// finished report (uploading it, posting it to a chat we don't speak,
+// printing it), so commands can be hooked in too, from --hook or
+// Notify.Commands in the config.  Each runs through the shell with:
+//
+//	GHREVIEW_REPORT    the report's path, or the first one's
+//	GHREVIEW_REPORTS   every report's path, a line each
+//	GHREVIEW_SUMMARY   the summary, as JSON, like webhooks get
+//
+// Whatever a hook prints goes to stderr, since stdout may be the report.
+//
+
+var hooks stringList
+
+type commandNotifier struct {
+	command string
+}
+
+func (n commandNotifier) Notify(s Summary) error {
+	summary, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	first := ""
+	if len(s.Reports) > 0 {
+		first = s.Reports[0]
+	}
+
+	cmd := exec.Command("sh", "-c", n.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", n.command)
+	}
+	cmd.Env = append(os.Environ(),
+		"GHREVIEW_REPORT="+first,
+		"GHREVIEW_REPORTS="+strings.Join(s.Reports, "\n"),
+		"GHREVIEW_SUMMARY="+string(summary))
+	cmd.Stdout = statusLine
+	cmd.Stderr = statusLine
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q: %s", n.command, err)
+	}
+	return nil
+}
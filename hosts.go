@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// Repos normally live on github.com, but they can also be spelled with a
+// hostname in front (e.g. github.example.com/owner/repo) to point at a Github
+// Enterprise instance.  Each host gets its own token and its own rate-limit
+// bookkeeping, so that being throttled on one doesn't slow down the other.
+//
+
+const defaultHost string = "github.com"
+
+const defaultMinInterval time.Duration = 5000 * time.Millisecond
+
+// minInterval is the least we wait between requests to the same host, to
+// prevent us from getting rate-limited.
+var minInterval time.Duration = defaultMinInterval
+
+type Host struct {
+	Name  string
+	API   string
+	Token string
+
+	mu        sync.Mutex
+	next      time.Time
+	remaining int
+	reset     time.Time
+}
+
+var hosts = map[string]*Host{}
+
+var hostsMu sync.Mutex
+
+func hostFor(name string) *Host {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+
+	if h, ok := hosts[name]; ok {
+		return h
+	}
+
+	h := &Host{Name: name, API: "https://api.github.com", remaining: -1}
+	if name != defaultHost {
+		h.API = fmt.Sprintf("https://%s/api/v3", name)
+	}
+	if hc, ok := config.Hosts[name]; ok && hc.API != "" {
+		h.API = strings.TrimSuffix(hc.API, "/")
+	}
+	h.Token = tokenFor(name)
+	hosts[name] = h
+	return h
+}
+
+// tokenFor looks in the config first, and then in the same environment
+// variables that the gh CLI uses.
+func tokenFor(name string) string {
+	if hc, ok := config.Hosts[name]; ok {
+		if hc.Token != "" {
+			return hc.Token
+		}
+		if hc.TokenEnv != "" {
+			return os.Getenv(hc.TokenEnv)
+		}
+	}
+
+	vars := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if name != defaultHost {
+		vars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, v := range vars {
+		if token := os.Getenv(v); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// hostForAPI finds the host whose API lives at apiHost, e.g. api.github.com.
+func hostForAPI(apiHost string) *Host {
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+
+	for _, h := range hosts {
+		if u, err := url.Parse(h.API); err == nil && u.Host == apiHost {
+			return h
+		}
+	}
+	return nil
+}
+
+// splitRepo turns "owner/repo" or "host/owner/repo" into the host and the
+// owner/repo part.
+func splitRepo(repo string) (*Host, string) {
+	parts := strings.Split(repo, "/")
+	switch len(parts) {
+	case 2:
+		return hostFor(defaultHost), repo
+	case 3:
+		return hostFor(parts[0]), parts[1] + "/" + parts[2]
+	}
+	log.Fatalf("expected owner/repo or host/owner/repo, got %q", repo)
+	return nil, ""
+}
+
+// configuredHosts is github.com, and then every other host in the config.
+func configuredHosts() []string {
+	var others []string
+	for name := range config.Hosts {
+		if name != defaultHost {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append([]string{defaultHost}, others...)
+}
+
+func repoURL(repo string, format string, args ...any) string {
+	host, name := splitRepo(repo)
+	return fmt.Sprintf("%s/repos/%s", host.API, name) + fmt.Sprintf(format, args...)
+}
+
+func (h *Host) authorization() string {
+	if h.Token == "" {
+		return ""
+	}
+	return "token " + h.Token
+}
+
+// wait blocks until we're allowed to send another request to this host.  It
+// claims its slot before sleeping, so that requests made concurrently (e.g.
+// by prefetching) still go out one at a time.
+func (h *Host) wait() {
+	h.mu.Lock()
+	next := h.next
+	if h.remaining == 0 && h.reset.After(next) {
+		log.Printf("out of API quota for %s, waiting until %s", h.Name, h.reset.Format(time.Kitchen))
+		next = h.reset
+	}
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	h.next = next.Add(h.interval())
+	h.mu.Unlock()
+
+	time.Sleep(time.Until(next))
+}
+
+func (h *Host) update(resp *http.Response) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		h.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		h.reset = time.Unix(reset, 0)
+	}
+	h.next = time.Now().Add(h.interval())
+}
+
+// interval is how long to wait between requests right now.  With plenty of
+// quota that's just minInterval, but as the quota runs low we spread what's
+// left evenly until the reset, so that we slow down instead of running out.
+// The caller must hold h.mu.
+func (h *Host) interval() time.Duration {
+	interval := minInterval
+	if h.remaining > 0 {
+		if spread := time.Until(h.reset) / time.Duration(h.remaining); spread > interval {
+			interval = spread
+		}
+	}
+	return interval
+}
+
+// authTransport sits in front of the cache, so that responses are cached
+// per set of credentials.
+type authTransport struct {
+	next http.RoundTripper
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := hostForAPI(req.URL.Host)
+	if h == nil || h.Token == "" || req.Header.Get("Authorization") != "" {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", h.authorization())
+	return t.next.RoundTrip(req)
+}
+
+// pacingTransport sits behind the cache, so only requests that actually go
+// to the wire count against a host.
+type pacingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := hostForAPI(req.URL.Host)
+	if h == nil {
+		return t.next.RoundTrip(req)
+	}
+	h.wait()
+
+	span := startSpan("fetch", "http.method", req.Method, "http.url", req.URL.String())
+	defer span.End()
+
+	usage.network.Add(1)
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		h.update(resp)
+		span.set("http.status_code", strconv.Itoa(resp.StatusCode))
+		resp.Body = &countingReader{resp.Body}
+	}
+	return resp, err
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// All of our HTTP goes through cachingTransport, so anything we fetch ends up
+// in the cache without the caller having to think about it.  Responses are
+// stored whole (status line, headers and body) under a key made from the auth
+// scope and the URL, e.g.
+//
+//	anonymous/api.github.com/repos/owner/repo/pulls/page=1&state=all.http
+//
+// By default stored responses never expire, which is what lets an archive
+// re-render offline.  With --ttl, a response is reused for the longer of the
+// TTL and its Cache-Control max-age, after which we revalidate it using its
+// ETag or Last-Modified.  If revalidation fails because we can't reach the
+// server, we make do with the stale copy.
+//
+// Each response also carries a checksum of its body, because cache
+// directories on network drives and sync services do get truncated and
+// garbled.  An entry that doesn't match its checksum is treated as missing,
+// and so gets refetched and overwritten.
+//
+
+const anonymousScope string = "anonymous"
+
+const cachedAtHeader string = "X-Ghreview-Cached-At"
+
+const fromCacheHeader string = "X-From-Cache"
+
+const checksumHeader string = "X-Ghreview-Sha256"
+
+var ttl time.Duration
+
+var client = &http.Client{
+	Transport: &authTransport{&cachingTransport{&pacingTransport{http.DefaultTransport}}},
+}
+
+type cachingTransport struct {
+	next http.RoundTripper
+}
+
+// Requests whose context carries neverStale are served from the cache
+// whenever possible, whatever --ttl says.
+type neverStale struct{}
+
+// Requests whose context carries bypassCache always go to the wire, and
+// their responses aren't stored.
+type bypassCache struct{}
+
+// POSTs whose context carries cacheablePost are really queries (GraphQL),
+// so we cache them like GETs, keyed by what they asked.
+type cacheablePost struct{}
+
+// authScope keeps responses fetched with different credentials apart, since
+// what the API shows you depends on who you are.  We hash the credentials so
+// that they never end up on disk.
+func authScope(authorization string) string {
+	if authorization == "" {
+		return anonymousScope
+	}
+	sum := sha256.Sum256([]byte(authorization))
+	return hex.EncodeToString(sum[:8])
+}
+
+func cacheKey(req *http.Request) string {
+	leaf := "index"
+	if req.URL.RawQuery != "" {
+		leaf = req.URL.Query().Encode()
+	}
+	if req.Method == http.MethodPost && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			sum := sha256.Sum256(data)
+			leaf = "post-" + hex.EncodeToString(sum[:16])
+		}
+	}
+	path := strings.Trim(req.URL.Path, "/")
+	return fmt.Sprintf("%s/%s/%s/%s.http", authScope(req.Header.Get("Authorization")), req.URL.Host, path, leaf)
+}
+
+// repoKeyPrefix is where everything we've fetched about repo lives.
+func repoKeyPrefix(repo string) string {
+	host, _ := splitRepo(repo)
+	u, err := url.Parse(repoURL(repo, ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+	return fmt.Sprintf("%s/%s/%s/", authScope(host.authorization()), u.Host, strings.Trim(u.Path, "/"))
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cacheable := req.Method == http.MethodGet || (req.Method == http.MethodPost && req.Context().Value(cacheablePost{}) != nil)
+	if !cacheable || req.Context().Value(bypassCache{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	if req.Context().Value(neverStale{}) != nil {
+		if cached := loadResponse(key, req); cached != nil {
+			usage.cacheHits.Add(1)
+			return cached, nil
+		}
+	}
+	cached := loadResponse(key, req)
+	if cached != nil && isFresh(cached) {
+		usage.cacheHits.Add(1)
+		return cached, nil
+	}
+
+	//
+	// If we share the cache with other people, make sure only one of us goes
+	// to the wire for this, and let everybody else wait for the result.
+	//
+	if locker, ok := backend().(Locker); ok {
+		for {
+			locked, err := locker.Lock(key)
+			if err != nil {
+				return nil, fmt.Errorf("unable to lock %s: %w", key, err)
+			}
+			if locked {
+				break
+			}
+			time.Sleep(time.Second)
+			if resp := loadResponse(key, req); resp != nil && isFresh(resp) {
+				usage.cacheHits.Add(1)
+				return resp, nil
+			}
+		}
+		defer locker.Unlock(key)
+
+		if resp := loadResponse(key, req); resp != nil {
+			if isFresh(resp) {
+				usage.cacheHits.Add(1)
+				return resp, nil
+			}
+			cached = resp
+		}
+	}
+
+	outgoing := req
+	if cached != nil {
+		outgoing = req.Clone(req.Context())
+		if etag := cached.Header.Get("Etag"); etag != "" {
+			outgoing.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			outgoing.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	if cached != nil {
+		log.Printf("revalidating stale %s", req.URL)
+	} else {
+		log.Printf("cache miss, reading %s from the wire", req.URL)
+	}
+	resp, err := t.next.RoundTrip(outgoing)
+	if err != nil {
+		if cached != nil {
+			log.Printf("unable to revalidate %s, using the stale copy: %s", req.URL, err)
+			usage.cacheHits.Add(1)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		usage.notModified.Add(1)
+		resp.Body.Close()
+		for _, h := range []string{"Cache-Control", "Date", "Etag", "Expires", "Last-Modified"} {
+			if v := resp.Header.Get(h); v != "" {
+				cached.Header.Set(h, v)
+			}
+		}
+		return storeResponse(key, cached)
+	}
+
+	if resp.StatusCode != http.StatusOK || hasDirective(resp, "no-store") {
+		return resp, nil
+	}
+	return storeResponse(key, resp)
+}
+
+func loadResponse(key string, req *http.Request) *http.Response {
+	data, err := readCache(key)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		log.Printf("ignoring unreadable cache entry %s: %s", key, err)
+		return nil
+	}
+	if !compatibleEntry(resp) {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Printf("ignoring truncated cache entry %s: %s", key, err)
+		return nil
+	}
+	if sum := resp.Header.Get(checksumHeader); sum != "" && sum != checksum(body) {
+		log.Printf("ignoring corrupt cache entry %s, its checksum doesn't match", key)
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.Header.Set(fromCacheHeader, "1")
+	return resp
+}
+
+func storeResponse(key string, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Header.Del(fromCacheHeader)
+	resp.Header.Set(cachedAtHeader, time.Now().UTC().Format(time.RFC3339))
+	resp.Header.Set(schemaHeader, strconv.Itoa(cacheSchema))
+	resp.Header.Set(checksumHeader, checksum(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	resp.Header.Del("Transfer-Encoding")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+
+	data, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	writeCache(key, data)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func isFresh(resp *http.Response) bool {
+	if ttl == 0 {
+		return true
+	}
+	if hasDirective(resp, "no-cache") {
+		return false
+	}
+
+	cachedAt, err := time.Parse(time.RFC3339, resp.Header.Get(cachedAtHeader))
+	if err != nil {
+		return false
+	}
+	lifetime := ttl
+	if maxAge := maxAge(resp); maxAge > lifetime {
+		lifetime = maxAge
+	}
+	return time.Since(cachedAt) < lifetime
+}
+
+func cacheDirectives(resp *http.Response) []string {
+	var directives []string
+	for _, d := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		directives = append(directives, strings.ToLower(strings.TrimSpace(d)))
+	}
+	return directives
+}
+
+func hasDirective(resp *http.Response, directive string) bool {
+	for _, d := range cacheDirectives(resp) {
+		if d == directive {
+			return true
+		}
+	}
+	return false
+}
+
+func maxAge(resp *http.Response) time.Duration {
+	for _, d := range cacheDirectives(resp) {
+		if value, ok := strings.CutPrefix(d, "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+//
+// People are known by the name of their identity in the config, if they have
+// one, and by their login otherwise.  Logins and emails are compared without
+// regard to case, the same way Github does.
+//
+
+const defaultUser string = "mpenkov"
+
+var user string = defaultUser
+
+// myLogins is every login the user has gone by.
+func myLogins() []string {
+	return loginsOf(user)
+}
+
+// loginsOf is every login person has gone by.
+func loginsOf(person string) []string {
+	if id, ok := config.Identities[person]; ok && len(id.Logins) > 0 {
+		return id.Logins
+	}
+	return []string{person}
+}
+
+// myAuthors is everything the commits API might know the user's commits by.
+func myAuthors() []string {
+	authors := append([]string{}, myLogins()...)
+	return append(authors, config.Identities[user].Emails...)
+}
+
+func isMe(login string) bool {
+	for _, l := range myLogins() {
+		if strings.EqualFold(l, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// personForEmail maps a commit author to whoever they are, falling back to
+// the name they committed under.
+func personForEmail(email string, name string) string {
+	for person, id := range config.Identities {
+		for _, e := range id.Emails {
+			if strings.EqualFold(e, email) {
+				return person
+			}
+		}
+	}
+	return name
+}
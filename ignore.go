@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//
+// Some PRs shouldn't count: a bot's that we happened to merge, one opened by
+// accident, a mass rename.  The ignore file lists them one per line, as
+// owner/repo#number (host/owner/repo#number for other hosts), and anything
+// after a # at the start of a line or after whitespace is a comment:
+//
+//	# dependabot went wild in March
+//	acme/widgets#1204
+//	acme/widgets#1210  # reverted the next day
+//
+// Ignored PRs are left out of the counts and the tables alike.
+//
+
+const defaultIgnorePath string = "ghreview.ignore"
+
+var ignorePath string
+
+var ignoredPulls = map[string]bool{}
+
+func loadIgnored(path string) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultIgnorePath {
+		return
+	} else if err != nil {
+		log.Fatalf("unable to read %s: %s", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if i := strings.Index(line, "\t#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		repo, number, ok := strings.Cut(line, "#")
+		if _, err := strconv.Atoi(number); !ok || err != nil || !strings.Contains(repo, "/") {
+			log.Fatalf("%s:%d: expected owner/repo#number, not %q", path, n, line)
+		}
+		ignoredPulls[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("unable to read %s: %s", path, err)
+	}
+}
+
+// ignored says whether the ignore file lists the PR, going by either the
+// repo as we were given it or its owner/repo name.
+func ignored(repo string, number int) bool {
+	if len(ignoredPulls) == 0 {
+		return false
+	}
+	_, name := splitRepo(repo)
+	suffix := "#" + strconv.Itoa(number)
+	return ignoredPulls[strings.ToLower(repo+suffix)] || ignoredPulls[strings.ToLower(name+suffix)]
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//
+// Corporate PRs tend to mention Jira tickets (PROJ-123) in their titles or
+// branch names.  We link those up, and optionally ask Jira which epic each
+// ticket belongs to, so that the report can show work per epic.
+//
+
+const jiraTracker string = "Jira"
+
+const noEpic string = "No epic"
+
+type Epic struct {
+	Key   string
+	URL   string
+	Pulls []TicketPull
+}
+
+var ticketKey = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+-[0-9]+)\b`)
+
+func jiraEnabled() bool {
+	return config.Jira != nil && config.Jira.URL != ""
+}
+
+func jiraURL(key string) string {
+	return fmt.Sprintf("%s/browse/%s", strings.TrimSuffix(config.Jira.URL, "/"), key)
+}
+
+func jiraTickets(texts ...string) []Ticket {
+	var tickets []Ticket
+	for _, text := range texts {
+		for _, key := range ticketKey.FindAllString(strings.ToUpper(text), -1) {
+			if knownProject(key) {
+				tickets = append(tickets, Ticket{jiraTracker, key, jiraURL(key)})
+			}
+		}
+	}
+	return tickets
+}
+
+func knownProject(key string) bool {
+	if len(config.Jira.Projects) == 0 {
+		return true
+	}
+	project, _, _ := strings.Cut(key, "-")
+	for _, p := range config.Jira.Projects {
+		if strings.EqualFold(p, project) {
+			return true
+		}
+	}
+	return false
+}
+
+// epicOf asks Jira which epic key belongs to, if any.
+func epicOf(key string) string {
+	fields := "parent"
+	if config.Jira.EpicField != "" {
+		fields = config.Jira.EpicField
+	}
+	url := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=%s", strings.TrimSuffix(config.Jira.URL, "/"), key, fields)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	token := config.Jira.Token
+	if config.Jira.TokenEnv != "" {
+		token = os.Getenv(config.Jira.TokenEnv)
+	}
+	if config.Jira.User != "" {
+		req.SetBasicAuth(config.Jira.User, token)
+	} else if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ""
+	} else if err != nil || resp.StatusCode > 299 {
+		log.Fatalf("unable to look up %s in Jira: HTTP %d", key, resp.StatusCode)
+	}
+
+	var issue struct {
+		Fields map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(data, &issue); err != nil {
+		log.Fatalf("JSON unmarshalling failed: %s", err)
+	}
+
+	//
+	// The parent is an object with a key; an epic link field is just the key.
+	//
+	raw := issue.Fields[fields]
+	var parent struct {
+		Key string
+	}
+	if json.Unmarshal(raw, &parent) == nil && parent.Key != "" {
+		return parent.Key
+	}
+	var epic string
+	json.Unmarshal(raw, &epic)
+	return epic
+}
+
+func epics(results []RepoResult) []Epic {
+	byKey := map[string]*Epic{}
+	epicCache := map[string]string{}
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			added := map[string]bool{}
+			for _, t := range p.Tickets {
+				if t.Tracker != jiraTracker {
+					continue
+				}
+				epic, ok := epicCache[t.Key]
+				if !ok {
+					epic = epicOf(t.Key)
+					epicCache[t.Key] = epic
+				}
+				if epic == "" {
+					epic = noEpic
+				}
+				if added[epic] {
+					continue
+				}
+				added[epic] = true
+
+				if byKey[epic] == nil {
+					byKey[epic] = &Epic{Key: epic}
+					if epic != noEpic {
+						byKey[epic].URL = jiraURL(epic)
+					}
+				}
+				byKey[epic].Pulls = append(byKey[epic].Pulls, TicketPull{result.Name, p})
+			}
+		}
+	}
+
+	var epics []Epic
+	for _, e := range byKey {
+		epics = append(epics, *e)
+	}
+	sort.Slice(epics, func(i, j int) bool {
+		if (epics[i].Key == noEpic) != (epics[j].Key == noEpic) {
+			return epics[j].Key == noEpic
+		}
+		return epics[i].Key < epics[j].Key
+	})
+	return epics
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//
+// Where PR titles follow Conventional Commits (feat: ..., fix(parser): ...),
+// the prefixes say what kind of work the year was: features, fixes, docs,
+// chores.  We only show the breakdown if at least some titles follow the
+// convention; everything else counts as "other".  Only the types the
+// convention defines count, so that "README: typo" or "WIP: ..." don't turn
+// into kinds of their own.
+//
+
+const otherKind string = "other"
+
+var conventionalPrefix = regexp.MustCompile(`^\s*(?i:(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert))(\([^)]*\))?!?:`)
+
+type KindCount struct {
+	Kind    string
+	Count   int
+	Percent int
+}
+
+func workKind(title string) string {
+	if m := conventionalPrefix.FindStringSubmatch(title); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return otherKind
+}
+
+// workKinds breaks down my authored PRs by kind, most common first.
+func workKinds(results []RepoResult) []KindCount {
+	counts := map[string]int{}
+	total := 0
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			if p.MyContribution == "authored" {
+				counts[workKind(p.Title)]++
+				total++
+			}
+		}
+	}
+	if total == 0 || counts[otherKind] == total {
+		return nil
+	}
+
+	var kinds []KindCount
+	for kind, count := range counts {
+		kinds = append(kinds, KindCount{kind, count, 100 * count / total})
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Count != kinds[j].Count {
+			return kinds[i].Count > kinds[j].Count
+		}
+		return kinds[i].Kind < kinds[j].Kind
+	})
+	return kinds
+}
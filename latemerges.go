@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+//
+// Going by creation date, a PR that was opened in one year and merged in the
+// next counts only for the first.  With --late-merges, we also search for PRs
+// merged during the year but created before it, so long-lived PRs show up in
+// the year they actually landed.  (With --match-on merged or closed, the
+// regular listing already finds those.)
+//
+
+// The search API won't go past 1000 results, in pages of at most 100.
+const (
+	searchPageSize int = 100
+	searchMaxPages int = 10
+)
+
+var lateMerges bool
+
+type searchResult struct {
+	TotalCount int `json:"total_count"`
+	Items      []Issue
+}
+
+func searchURL(repo string, query string, page int) string {
+	host, _ := splitRepo(repo)
+	return fmt.Sprintf("%s/search/issues?q=%s&sort=created&order=desc&per_page=%d&page=%d", host.API, url.QueryEscape(query), searchPageSize, page)
+}
+
+// searchAll is every result for query, as far as the search API will go.
+func searchAll(repo string, query string) []Issue {
+	var all []Issue
+	for page := 1; page <= searchMaxPages; page++ {
+		var found searchResult
+		fetch(searchURL(repo, query, page), &found)
+		all = append(all, found.Items...)
+		if len(found.Items) < searchPageSize || page*searchPageSize >= found.TotalCount {
+			break
+		}
+	}
+	return all
+}
+
+func collectLateMerges(repo string, year int, result *RepoResult) {
+	_, name := splitRepo(repo)
+	start, end := periodBounds(year)
+	end = end.Add(-time.Second)
+	query := fmt.Sprintf("repo:%s is:pr is:merged merged:%s..%s created:<%s",
+		name, start.Format(time.RFC3339), end.Format(time.RFC3339), start.Format(time.RFC3339))
+
+	have := map[int]bool{}
+	for _, p := range result.Pulls {
+		have[p.Number] = true
+	}
+
+	for _, issue := range searchAll(repo, query) {
+		if have[issue.Number] || issue.PullRequest == nil || ignored(repo, issue.Number) {
+			continue
+		}
+		have[issue.Number] = true
+
+		//
+		// Search results don't say which branch the PR went into.
+		//
+		if filteringBranches() && !countsBase(repo, loadPull(repo, issue.Number)) {
+			continue
+		}
+
+		p := Pull{
+			Number:    issue.Number,
+			HtmlUrl:   issue.HtmlUrl,
+			CreatedAt: issue.CreatedAt,
+			MergedAt:  issue.PullRequest.MergedAt,
+			ClosedAt:  issue.ClosedAt,
+			State:     issue.State,
+			Title:     issue.Title,
+			User:      issue.User,
+			Timestamp: issue.PullRequest.MergedAt,
+		}
+		if p.MyContribution = classify(repo, p); p.MyContribution == "" {
+			continue
+		}
+		if touchesPaths(repo, p) {
+			result.add(p)
+		}
+	}
+}
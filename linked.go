@@ -0,0 +1,120 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Features that span repos get delivered as several PRs, say one to the
+// library and one to the app that uses it, and they tend to mention each
+// other.  With --linked, we read the titles and descriptions of my PRs for
+// references to other repos (owner/repo#N, or a link to the PR), and group
+// PRs that reference each other, directly or not, into clusters.  Only
+// clusters that span more than one repo make it into the report; the rest
+// is just business as usual.
+//
+
+var linkedWork bool
+
+var (
+	shortRefPattern = regexp.MustCompile(`([\w.-]+/[\w.-]+)#(\d+)\b`)
+	urlRefPattern   = regexp.MustCompile(`https?://[^/\s]+/([\w.-]+/[\w.-]+)/(?:pull|issues)/(\d+)\b`)
+)
+
+type LinkedPull struct {
+	Repo string
+	Pull
+}
+
+type Cluster struct {
+	Repos []string
+	Pulls []LinkedPull
+}
+
+// pullRef is how we key PRs across repos: owner/repo#N, in lower case.
+// Hosts are left out, since references within a host leave them out too.
+func pullRef(name string, number int) string {
+	return strings.ToLower(name) + "#" + strconv.Itoa(number)
+}
+
+// findReferences fills in the references each PR makes to PRs elsewhere.
+func findReferences(repo string, pulls []Pull) {
+	_, name := splitRepo(repo)
+	for i := range pulls {
+		full := loadPull(repo, pulls[i].Number)
+		pulls[i].References = nil
+		text := pulls[i].Title + "\n" + full.Body
+		for _, pattern := range []*regexp.Regexp{shortRefPattern, urlRefPattern} {
+			for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+				number, err := strconv.Atoi(m[2])
+				if err != nil || strings.EqualFold(m[1], name) {
+					continue
+				}
+				pulls[i].References = append(pulls[i].References, pullRef(m[1], number))
+			}
+		}
+	}
+}
+
+// linkedClusters groups the PRs in results that reference each other, and
+// returns the groups that span repos, earliest first.
+func linkedClusters(results []RepoResult) []Cluster {
+	pulls := map[string]LinkedPull{}
+	for _, result := range results {
+		_, name := splitRepo(result.Name)
+		for _, p := range result.Pulls {
+			pulls[pullRef(name, p.Number)] = LinkedPull{result.Name, p}
+		}
+	}
+
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(key string) string {
+		if p, ok := parent[key]; ok && p != key {
+			parent[key] = find(p)
+			return parent[key]
+		}
+		return key
+	}
+	for key, lp := range pulls {
+		for _, ref := range lp.References {
+			if _, ok := pulls[ref]; ok {
+				parent[find(key)] = find(ref)
+			}
+		}
+	}
+
+	members := map[string][]LinkedPull{}
+	for key, lp := range pulls {
+		root := find(key)
+		members[root] = append(members[root], lp)
+	}
+
+	var clusters []Cluster
+	for _, lps := range members {
+		repos := map[string]bool{}
+		for _, lp := range lps {
+			repos[lp.Repo] = true
+		}
+		if len(repos) < 2 {
+			continue
+		}
+		sort.Slice(lps, func(i, j int) bool { return lps[i].Timestamp < lps[j].Timestamp })
+		c := Cluster{Pulls: lps}
+		for repo := range repos {
+			c.Repos = append(c.Repos, repo)
+		}
+		sort.Strings(c.Repos)
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Pulls[0].Timestamp < clusters[j].Pulls[0].Timestamp })
+	return clusters
+}
+
+// RepoList is the cluster's repos, for its heading.
+func (c Cluster) RepoList() string {
+	return strings.Join(c.Repos, ", ")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+//
+// Two runs sharing a cache directory would trip over each other's writes
+// and spend the rate limit twice on the same requests, so each run holds a
+// lockfile in the cache directory.  A lock left behind by a run that died on
+// this machine is taken over; anything else needs --force.  (The Redis cache
+// has its own, finer-grained locking.)
+//
+
+const lockName string = ".lock"
+
+type lockInfo struct {
+	Pid     int
+	Host    string
+	Started string
+}
+
+var forceLock bool
+
+var lockPath string
+
+func lockCache(dir string) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Fatalf("unable to create %s: %s", dir, err)
+	}
+	path := filepath.Join(dir, lockName)
+
+	hostname, _ := os.Hostname()
+	me := lockInfo{os.Getpid(), hostname, time.Now().UTC().Format(time.RFC3339)}
+	data, err := json.Marshal(me)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			f.Write(data)
+			f.Close()
+			lockPath = path
+			return
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			log.Fatalf("unable to lock %s: %s", dir, err)
+		}
+
+		var holder lockInfo
+		if existing, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(existing, &holder)
+		}
+		switch {
+		case forceLock:
+			log.Printf("overriding the lock on %s held by pid %d on %s since %s", dir, holder.Pid, holder.Host, holder.Started)
+		case holder.Host == hostname && !processAlive(holder.Pid):
+			log.Printf("taking over the lock on %s left behind by pid %d", dir, holder.Pid)
+		default:
+			log.Fatalf("%s is in use by pid %d on %s since %s; if that run is gone, use --force", dir, holder.Pid, holder.Host, holder.Started)
+		}
+		os.Remove(path)
+	}
+	log.Fatalf("unable to lock %s", dir)
+}
+
+func unlockCache() {
+	if lockPath != "" {
+		os.Remove(lockPath)
+		lockPath = ""
+	}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds on Windows if the process exists.
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
@@ -15,7 +15,9 @@ I mostly know the repos I worked on during the year.
 */
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
@@ -37,22 +39,102 @@ type Event struct {
 	Event string
 }
 
+type Label struct {
+	Name string
+}
+
+type Milestone struct {
+	Title string
+}
+
 type Pull struct {
 	Number    int
 	HtmlUrl   string `json:"html_url"`
 	CreatedAt string `json:"created_at"`
 	MergedAt  string `json:"merged_at"`
+	ClosedAt  string `json:"closed_at"`
+	UpdatedAt string `json:"updated_at"`
 	State     string
+	Draft     bool
 	Title     string
+	Body      string `json:",omitempty"`
 	User      User
+	Head      struct {
+		Ref string
+	}
+	Base struct {
+		Ref string
+	}
+	Labels    []Label
+	Milestone *Milestone
+	Assignees []User
+	// RequestedReviewers are whose reviews the PR is still waiting for.
+	RequestedReviewers []User `json:"requested_reviewers"`
+	// These are only on the individual PR endpoint, so we only fetch them
+	// when their columns are asked for.
+	Comments       int
+	ReviewComments int `json:"review_comments"`
+	Additions      int
+	Deletions      int
+	// MergedBy is only there if the PR came from the individual PR endpoint,
+	// a webhook or GraphQL; the list endpoint leaves it out.
+	MergedBy *User `json:"merged_by"`
+	// MergeCommitSha is what the PR landed as, if it was merged.
+	MergeCommitSha string `json:"merge_commit_sha,omitempty"`
+
+	// reviews are filled in by batched GraphQL lookups, if we did those.
+	reviews     []Review
+	haveReviews bool
 
 	MyContribution string
-	Timestamp      string
+	Tickets        []Ticket
+	// Note is what the notes file says about the PR, if anything.
+	Note     string
+	Category string
+	// References are the PRs in other repos that this one mentions, as
+	// owner/repo#N, with --linked.
+	References []string `json:",omitempty"`
+	// AfterHours says the contribution was made on a weekend or outside
+	// working hours, with --after-hours.
+	AfterHours bool `json:",omitempty"`
+	// Timestamp is when the contribution happened, as an ISO 8601 timestamp
+	// straight from the API; the renderers take care of formatting it.
+	Timestamp string
+}
+
+// DisplayState is the state we show: drafts get one of their own, since they
+// aren't really open for review.
+func (p Pull) DisplayState() string {
+	if p.Draft {
+		return "draft"
+	}
+	return p.State
+}
+
+type Issue struct {
+	Number      int
+	NodeID      string `json:"node_id"`
+	HtmlUrl     string `json:"html_url"`
+	CreatedAt   string `json:"created_at"`
+	ClosedAt    string `json:"closed_at"`
+	State       string
+	Title       string
+	User        User
+	PullRequest *struct {
+		MergedAt string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+type Review struct {
+	User        User
+	State       string
+	SubmittedAt string `json:"submitted_at"`
 }
 
 //
 // For sorting
 //
+
 type PullList []Pull
 
 func (pl PullList) Len() int {
@@ -68,17 +150,59 @@ func (pl PullList) Swap(i, j int) {
 }
 
 type RepoResult struct {
-	Name     string
-	Pulls    []Pull
-	Authored int
-	Merged   int
+	Name      string
+	Pulls     []Pull
+	Authored  int
+	Merged    int
+	Reviewed  int
+	Committed int
+	Direct    int
+	Score     float64
+	Commits   []AuthorStats
+	// Mentored are the PRs by --mentee people, whatever I did with them.
+	Mentored []MenteePull `json:",omitempty"`
+	// Advisories are the security advisories I published, with --security.
+	Advisories []Advisory `json:",omitempty"`
+	// OpenAtEnd is what was still open at the end of the year, with
+	// --open-at-end.
+	OpenAtEnd []Issue `json:",omitempty"`
+	// Resolved are the issues closed by PRs I authored or merged, with
+	// --resolved.
+	Resolved []ResolvedIssue `json:",omitempty"`
+	// Assigned are the issues and PRs assigned to me during the period that
+	// I didn't otherwise contribute to, with --assigned.
+	Assigned []Assignment `json:",omitempty"`
+	// Hotspots are the files my authored PRs changed most, with --hotspots.
+	Hotspots []Hotspot `json:",omitempty"`
+	// Depth is how many comments went back and forth, with --review-depth.
+	Depth *ReviewDepth `json:",omitempty"`
+	// Verdicts are how my reviews came out, with --review-stats.
+	Verdicts *ReviewStats `json:",omitempty"`
+	// Mentions are how often others pulled me in, with --mentions.
+	Mentions *Mentions `json:",omitempty"`
+	// Support is my comments on issues other people opened, with --support.
+	Support *SupportWork `json:",omitempty"`
+	// Wiki is what I did to the repo's wiki, with --wiki.
+	Wiki *WikiEdits `json:",omitempty"`
+	// Signing is how much of my work was signed, with --signing.
+	Signing *SigningStats `json:",omitempty"`
+	// AfterHours counts the Pulls made after hours.
+	AfterHours int `json:",omitempty"`
 }
 
-const header string = `<html>
+const header string = `<!DOCTYPE html>
+<html lang="en">
 <head>
+<meta charset="utf-8">
 <style>
 body {
-    font-family: sans-serif;
+    font-family: var(--font);
+    color: var(--text);
+    background-color: var(--background);
+}
+
+a {
+    color: var(--link);
 }
 
 table thead th {
@@ -92,30 +216,125 @@ table {
 }
 
 tbody tr:nth-child(odd) {
-    background-color: hsl(0, 100%, 100%);
+    background-color: var(--row-odd);
 }
 
 tbody tr:nth-child(even) {
-    background-color: hsl(0, 0%, 90%);
+    background-color: var(--row-even);
 }
 
 th, td {
-    border: 1px solid black;
+    border: 1px solid var(--border);
     padding: 5px;
 }
 
 td.state-open {
-    color: hsl(0, 90%, 50%);
+    color: var(--state-open);
 }
 td.state-closed {
-    color: hsl(240, 100%, 50%);
+    color: var(--state-closed);
+}
+td.state-draft {
+    color: var(--state-draft);
 }
-
 td.contribution-authored {
-    color: hsl(120, 80%, 40%);
+    color: var(--contribution-authored);
 }
 td.contribution-merged {
-    color: hsl(240, 100%, 50%);
+    color: var(--contribution-merged);
+}
+td.contribution-reviewed {
+    color: var(--contribution-reviewed);
+}
+td.contribution-committed {
+    color: var(--contribution-committed);
+}
+td.contribution-review-requested {
+    color: var(--contribution-review-requested);
+}
+
+td.state-open::before {
+    content: "\25CB\00A0";
+}
+td.state-closed::before {
+    content: "\25CF\00A0";
+}
+td.state-draft::before {
+    content: "\25CC\00A0";
+}
+td.state-draft {
+    font-style: italic;
+}
+
+header.profile {
+    display: flex;
+    gap: 1em;
+    align-items: center;
+}
+header.profile img {
+    border-radius: 50%;
+}
+img.avatar {
+    border-radius: 50%;
+    vertical-align: middle;
+}
+header.profile p {
+    margin: 0.2em 0;
+}
+header.profile .name {
+    font-size: 150%;
+}
+header.profile .login, header.profile .where {
+    color: var(--muted);
+}
+
+td code, li code {
+    font-size: 90%;
+    background: var(--code-background);
+    padding: 0 0.2em;
+    border-radius: 3px;
+}
+
+caption {
+    position: absolute;
+    width: 1px;
+    height: 1px;
+    overflow: hidden;
+    clip: rect(0 0 0 0);
+}
+
+td.rollup {
+    font-style: italic;
+    color: var(--muted);
+}
+
+td.after-hours::after {
+    content: "\00A0\263E";
+}
+
+p.preview {
+    border: 2px dashed var(--accent);
+    padding: 0.5em 1em;
+}
+
+tbody tr.away {
+    background: repeating-linear-gradient(135deg, var(--away), var(--away) 4px, var(--away-alternate) 4px, var(--away-alternate) 8px);
+    color: var(--muted);
+}
+
+td.note {
+    font-style: italic;
+    white-space: pre-line;
+}
+
+span.label {
+    border: 1px solid var(--label-border);
+    border-radius: 3px;
+    padding: 0 3px;
+}
+
+h1.group {
+    border-bottom: 3px solid var(--accent);
 }
 
 td {
@@ -124,72 +343,458 @@ td {
     white-space: nowrap;
     max-width: 400px;
 }
-</style>
-</head>
-<body>`
+
+div.toolbar {
+    margin-bottom: 1em;
+}
+</style>`
+
+const bodyStart string = `</head>
+<body>
+<main>`
+
+const footer string = `</main>
+</body>
+</html>`
 
 const templ string = `
+{{ define "repo" }}
+<section>
 <h1>{{ .Name }}</h1>
-<p>Authored {{ .Authored }} and merged {{ .Merged }} contributions.</p>
+{{ template "repo body" . }}
+</section>
+{{ end }}
+
+{{ define "group" }}
+<section>
+<h1 class="group">{{ .Name }}</h1>
+<p>{{ len .Repos }} repos: authored {{ .Authored }} and merged {{ .Merged }} contributions{{ if .Reviewed }}, and reviewed {{ .Reviewed }} more{{ end }}.</p>
+{{ if .Score }}<p>Activity score: {{ printf "%g" .Score }}</p>{{ end }}
+{{ range .Repos }}
+<h2>{{ .Name }}</h2>
+{{ template "repo body" . }}
+{{ end }}
+</section>
+{{ end }}
+
+{{ define "repo body" }}
+<p>Authored {{ .Authored }} and merged {{ .Merged }} contributions{{ if .Reviewed }}, and reviewed {{ .Reviewed }} more{{ end }}.</p>
+{{ if .Score }}<p>Activity score: {{ printf "%g" .Score }}</p>{{ end }}
+{{ if or .Committed .Direct }}<p>Landed commits via {{ .Committed }} other PRs, and pushed {{ .Direct }} commits directly.</p>{{ end }}
+{{ with .Resolved }}<p>Resolved {{ len . }} issues via PRs.</p>{{ end }}
+{{ with .Mentions }}<p>Was @mentioned in {{ .Threads }} issues and PRs by others, who also cross-referenced my PRs {{ .CrossReferences }} times.</p>{{ end }}
+{{ with .Support }}<p>Answered in {{ len .Threads }} issues opened by others, with {{ .Comments }} comments.</p>{{ end }}
+{{ with .Wiki }}<p>Made {{ .Edits }} edits to {{ .Pages }} wiki pages.</p>{{ end }}
+{{ with .Assigned }}<p>Was assigned {{ len . }} more issues and PRs.</p>{{ end }}
+{{ if .AfterHours }}<p>{{ .AfterHours }} of {{ len .Pulls }} contributions ({{ .AfterHoursShare }}) were on weekends or after hours.</p>{{ end }}
+{{ with .Depth }}<p>Got {{ .Received }} comments on {{ .Authored }} authored PRs ({{ .AverageReceived }} per PR), and left {{ .Given }} on {{ .Reviews }} PRs I reviewed ({{ .AverageGiven }} per PR).</p>{{ end }}
+{{ with .Signing }}<p>Github verified the signatures on {{ .VerifiedCommits }} of my {{ .Commits }} commits ({{ .CommitRatio }}){{ if gt .SignedCommits .VerifiedCommits }}, out of {{ .SignedCommits }} signed{{ end }}.{{ if .Merges }}  It verified {{ .VerifiedMerges }} of the {{ .Merges }} merges I did ({{ .MergeRatio }}).{{ end }}</p>{{ end }}
+{{ with .Verdicts }}{{ if .Total }}<p>Of my {{ .Total }} reviews, {{ .Approved }} approved ({{ .ApprovalRatio }}), {{ .ChangesRequested }} requested changes and {{ .Commented }} only commented.  Merged PRs took {{ .AverageRounds }} rounds of my reviews.</p>{{ end }}{{ end }}
+<table class="pulls">
+    <caption>PRs in {{ .Name }}</caption>
+    <thead>
+        <tr>
+            {{ if column "number" }}<th scope="col">#</th>{{ end }}
+            {{ if column "timestamp" }}<th scope="col">Timestamp</th>{{ end }}
+            {{ if column "state" }}<th scope="col">State</th>{{ end }}
+            {{ if column "contribution" }}<th scope="col">Contribution</th>{{ end }}
+            {{ if column "title" }}<th scope="col">Title</th>{{ end }}
+            {{ if column "labels" }}<th scope="col">Labels</th>{{ end }}
+            {{ if column "milestone" }}<th scope="col">Milestone</th>{{ end }}
+            {{ if column "assignees" }}<th scope="col">Assignees</th>{{ end }}
+            {{ if column "comments" }}<th scope="col">Comments</th>{{ end }}
+            {{ if column "size" }}<th scope="col">Size</th>{{ end }}
+            {{ if column "category" }}<th scope="col">Category</th>{{ end }}
+            {{ if tickets }}<th scope="col">Tickets</th>{{ end }}
+            {{ if notes }}<th scope="col">Notes</th>{{ end }}
+        </tr>
+    </thead>
+    <tbody>
+    {{ range .TablePulls }}
+        <tr>
+            {{ if column "number" }}<td><a href="{{ .HtmlUrl }}">{{ .Number }}</a></td>{{ end }}
+            {{ if column "timestamp" }}<td{{ if .AfterHours }} class="after-hours" title="after hours"{{ end }}>{{ timestamp .Timestamp }}</td>{{ end }}
+            {{ if column "state" }}<td class="state-{{ .DisplayState }}">{{ .DisplayState }}</td>{{ end }}
+            {{ if column "contribution" }}<td class="contribution-{{ .MyContribution }}">{{ .MyContribution }}</td>{{ end }}
+            {{ if column "title" }}<td><a href="{{ .HtmlUrl }}">{{ title .Title }}</a></td>{{ end }}
+            {{ if column "labels" }}<td>{{ range .Labels }}<span class="label">{{ .Name }}</span> {{ end }}</td>{{ end }}
+            {{ if column "milestone" }}<td>{{ with .Milestone }}{{ .Title }}{{ end }}</td>{{ end }}
+            {{ if column "assignees" }}<td>{{ .AssigneeLogins }}</td>{{ end }}
+            {{ if column "comments" }}<td>{{ .CommentCount }}</td>{{ end }}
+            {{ if column "size" }}<td>{{ .Size }}</td>{{ end }}
+            {{ if column "category" }}<td>{{ .Category }}</td>{{ end }}
+            {{ if tickets }}<td>{{ range .Tickets }}<a href="{{ .URL }}">{{ .Key }}</a> {{ end }}</td>{{ end }}
+            {{ if notes }}<td class="note">{{ .Note }}</td>{{ end }}
+        </tr>
+    {{ end }}
+    {{ with .DependencyUpdates }}
+        <tr>
+            <td class="rollup" colspan="{{ columnCount }}">Merged {{ . }} dependency updates</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ if .OpenAtEnd }}
+<h2>Still open at the end of the year</h2>
+<ul>
+    {{ range .OpenAtEnd }}
+    <li>{{ .Kind }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }}</li>
+    {{ end }}
+</ul>
+{{ end }}
+{{ if .Resolved }}
+<h2>Issues resolved via PRs</h2>
+<ul>
+    {{ range .Resolved }}
+    <li><a href="{{ .HtmlUrl }}">{{ .Label $.Name }}</a> {{ title .Title }}, by #{{ .Via }}</li>
+    {{ end }}
+</ul>
+{{ end }}
+{{ with .Support }}
+<h2>Community support</h2>
+<ul>
+    {{ range .Threads }}
+    <li><a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }}, by {{ .User.Login }} ({{ .Comments }} comments)</li>
+    {{ end }}
+</ul>
+{{ end }}
+{{ if .Assigned }}
+<h2>Assigned to me</h2>
+<ul>
+    {{ range .Assigned }}
+    <li>{{ .Kind }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }}, assigned {{ timestamp .AssignedAt }}</li>
+    {{ end }}
+</ul>
+{{ end }}
+{{ if .Hotspots }}
+<h2>Hotspots</h2>
 <table>
+    <caption>Files my PRs to {{ .Name }} changed most</caption>
     <thead>
         <tr>
-            <th>#</th>
-            <th>Timestamp</th>
-            <th>State</th>
-            <th>Contribution</th>
-            <th>Title</th>
+            <th scope="col">File</th>
+            <th scope="col">PRs</th>
         </tr>
     </thead>
     <tbody>
+    {{ range .Hotspots }}
+        <tr>
+            <td><code>{{ .Path }}</code></td>
+            <td>{{ .Pulls }}</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ end }}
+{{ if .Commits }}
+<h2>Commits</h2>
+<table>
+    <caption>Commits to {{ .Name }}</caption>
+    <thead>
+        <tr>
+            <th scope="col">Author</th>
+            <th scope="col">Commits</th>
+            <th scope="col">Files touched</th>
+            <th scope="col">Lines added</th>
+            <th scope="col">Lines removed</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range .Commits }}
+        <tr>
+            <td>{{ if .AvatarUrl }}<img class="avatar" src="{{ .AvatarUrl }}" alt="" width="20" height="20"> {{ end }}{{ .Author }}</td>
+            <td>{{ .Commits }}</td>
+            <td>{{ .Files }}</td>
+            <td>{{ .Added }}</td>
+            <td>{{ .Removed }}</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ end }}
+{{ end }}
+
+{{ define "goals" }}
+<section>
+<h1>Goals</h1>
+<table>
+    <caption>Progress towards goals</caption>
+    <thead>
+        <tr>
+            <th scope="col">Goal</th>
+            <th scope="col">Progress</th>
+            <th scope="col">Done</th>
+            <th scope="col">Complete</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range . }}
+        <tr>
+            <td>{{ .Name }}</td>
+            <td><progress value="{{ .Done }}" max="{{ .Target }}">{{ .Percent }}%</progress></td>
+            <td>{{ .Done }} of {{ .Target }}</td>
+            <td>{{ .Percent }}%</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+</section>
+{{ end }}
+
+{{ define "epics" }}
+<section>
+<h1>Epics</h1>
+{{ range . }}
+<h2>{{ if .URL }}<a href="{{ .URL }}">{{ .Key }}</a>{{ else }}{{ .Key }}{{ end }}</h2>
+<ul>
     {{ range .Pulls }}
+    <li>{{ .Repo }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }}</li>
+    {{ end }}
+</ul>
+{{ end }}
+</section>
+{{ end }}
+
+{{ define "tickets" }}
+<section>
+<h1>Tickets</h1>
+{{ range . }}
+<h2><a href="{{ .URL }}">{{ .Key }}</a></h2>
+<ul>
+    {{ range .Pulls }}
+    <li>{{ .Repo }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }}</li>
+    {{ end }}
+</ul>
+{{ end }}
+</section>
+{{ end }}
+
+{{ define "kinds" }}
+<section>
+<h1>Kinds of work</h1>
+<table>
+    <caption>Authored PRs by kind of work</caption>
+    <thead>
+        <tr>
+            <th scope="col">Kind</th>
+            <th scope="col">PRs</th>
+            <th scope="col">Share</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range . }}
         <tr>
+            <td>{{ .Kind }}</td>
+            <td>{{ .Count }}</td>
+            <td>{{ .Percent }}%</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+</section>
+{{ end }}
+
+{{ define "categories" }}
+<section>
+<h1>Categories</h1>
+<svg class="pie" width="120" height="120" viewBox="0 0 120 120" role="img" aria-label="Pie chart of contributions by category">
+{{ range . }}<path d="{{ .Slice }}" fill="{{ .Color }}"><title>{{ .Name }}: {{ .Count }}</title></path>{{ end }}
+</svg>
+<table>
+    <caption>Contributions by category</caption>
+    <thead>
+        <tr>
+            <th scope="col">Category</th>
+            <th scope="col">PRs</th>
+            <th scope="col">Share</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range . }}
+        <tr>
+            <td><svg width="10" height="10" aria-hidden="true"><rect width="10" height="10" fill="{{ .Color }}"/></svg> {{ .Name }}</td>
+            <td>{{ .Count }}</td>
+            <td>{{ .Percent }}%</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+</section>
+{{ end }}
+
+{{ define "linked" }}
+<section>
+<h1>Linked work across repos</h1>
+{{ range . }}
+<h2>{{ .RepoList }}</h2>
+<ul>
+    {{ range .Pulls }}
+    <li>{{ .Repo }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }} ({{ .MyContribution }})</li>
+    {{ end }}
+</ul>
+{{ end }}
+</section>
+{{ end }}
+
+{{ define "mentoring" }}
+<section>
+<h1>Mentoring {{ .Mentee }}</h1>
+<p>{{ .Mentee }} opened {{ .Opened }} PRs: I reviewed {{ .Reviewed }} and merged {{ .Merged }} of them.  {{ .Landed }} were merged, {{ .Closed }} closed without merging, and {{ .Open }} are still open.</p>
+{{ if .Pulls }}
+<table>
+    <caption>PRs by {{ .Mentee }}</caption>
+    <thead>
+        <tr>
+            <th scope="col">Repo</th>
+            <th scope="col">#</th>
+            <th scope="col">Timestamp</th>
+            <th scope="col">Title</th>
+            <th scope="col">Reviewed</th>
+            <th scope="col">Merged</th>
+            <th scope="col">Outcome</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range .Pulls }}
+        <tr>
+            <td>{{ .Repo }}</td>
             <td><a href="{{ .HtmlUrl }}">{{ .Number }}</a></td>
-            <td>{{ .Timestamp }}</td>
-            <td class="state-{{ .State }}">{{ .State }}</td>
-            <td class="contribution-{{ .MyContribution }}">{{ .MyContribution }}</td>
-            <td><a href="{{ .HtmlUrl }}">{{ .Title }}</a></td>
+            <td>{{ timestamp .Timestamp }}</td>
+            <td>{{ title .Title }}</td>
+            <td>{{ if .Reviewed }}yes{{ end }}</td>
+            <td>{{ if .Merged }}yes{{ end }}</td>
+            <td>{{ .Outcome }}</td>
         </tr>
     {{ end }}
     </tbody>
 </table>
-`
+{{ end }}
+</section>
+{{ end }}
 
-var report = template.Must(template.New("issuelist").Parse(templ))
+{{ define "security" }}
+<section>
+<h1>Security work</h1>
+{{ if .Advisories }}
+<table>
+    <caption>Security advisories I published</caption>
+    <thead>
+        <tr>
+            <th scope="col">Advisory</th>
+            <th scope="col">CVE</th>
+            <th scope="col">Severity</th>
+            <th scope="col">Published</th>
+            <th scope="col">Summary</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range .Advisories }}
+        <tr>
+            <td><a href="{{ .HtmlUrl }}">{{ .GhsaID }}</a></td>
+            <td>{{ .CveID }}</td>
+            <td>{{ .Severity }}</td>
+            <td>{{ timestamp .PublishedAt }}</td>
+            <td>{{ .Summary }}</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ end }}
+{{ if .Pulls }}
+<ul>
+    {{ range .Pulls }}
+    <li>{{ .Repo }} <a href="{{ .HtmlUrl }}">#{{ .Number }}</a> {{ title .Title }} ({{ .MyContribution }})</li>
+    {{ end }}
+</ul>
+{{ end }}
+</section>
+{{ end }}
 
-//
-// The cache functions are yet another work-around for Github API rate limiting
-//
-func readCache(path string) (data []byte, err error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
+{{ define "projects" }}
+<section>
+<h1>Project management</h1>
+<p>Added {{ .Added }} items to project boards, moved {{ .Moved }} and closed {{ .Closed }}.</p>
+{{ if .Items }}
+<table>
+    <caption>Project board items I added, moved or closed</caption>
+    <thead>
+        <tr>
+            <th scope="col">Project</th>
+            <th scope="col">Item</th>
+            <th scope="col">Action</th>
+            <th scope="col">Status</th>
+            <th scope="col">Timestamp</th>
+        </tr>
+    </thead>
+    <tbody>
+    {{ range .Items }}
+        <tr>
+            <td><a href="{{ .ProjectURL }}">{{ .Project }}</a></td>
+            <td>{{ with .URL }}<a href="{{ . }}">{{ end }}{{ with .Ref }}{{ . }} {{ end }}{{ title .Title }}{{ if .URL }}</a>{{ end }}</td>
+            <td>{{ .Action }}</td>
+            <td>{{ .Status }}</td>
+            <td>{{ timestamp .Timestamp }}</td>
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ end }}
+</section>
+{{ end }}
 
-	data, err = io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
+{{ define "gists" }}
+<section>
+<h1>Gists</h1>
+<p>Created {{ .Created }} gists and updated {{ .Updated }} more.</p>
+<ul>
+    {{ range .Gists }}
+    <li><a href="{{ .HtmlUrl }}">{{ .Title }}</a> ({{ .Action }} {{ timestamp .When }})</li>
+    {{ end }}
+</ul>
+</section>
+{{ end }}
+
+{{ define "scores" }}
+<section>
+<h1>Activity score by month</h1>
+<table>
+    <caption>Activity score by month</caption>
+    <thead>
+        <tr>
+            <th scope="col">Month</th>
+            <th scope="col">Score</th>
+            {{ if blackouts }}<th scope="col">Away</th>{{ end }}
+        </tr>
+    </thead>
+    <tbody>
+    {{ range . }}
+        <tr{{ if .Away }} class="away"{{ end }}>
+            <td>{{ .Month }}</td>
+            <td>{{ printf "%g" .Score }}</td>
+            {{ if blackouts }}<td>{{ .Away }}</td>{{ end }}
+        </tr>
+    {{ end }}
+    </tbody>
+</table>
+{{ if blackouts }}<p>Average: {{ activeAverage . }} that I wasn't away.</p>{{ end }}
+</section>
+{{ end }}
+`
+
+var report = template.Must(template.New("issuelist").Funcs(template.FuncMap{"tickets": ticketsEnabled, "notes": notesEnabled, "timestamp": displayTime, "column": showColumn, "columnCount": columnCount, "title": renderTitle, "blackouts": blackoutsEnabled, "activeAverage": activeAverage}).Parse(templ))
 
-	file.Close()
-	return data, nil
+const defaultYear int = 2021
+
+func httpGet(url string) []byte {
+	return httpGetContext(context.Background(), url)
 }
 
-func writeCache(path string, data []byte) {
-	// Why can't I just iterate over the chars and compare them to "/"?
-	subdir := path[:strings.LastIndex(path, "/")]
-	err := os.MkdirAll(subdir, 0700)
-	if err != nil {
-		log.Fatalf("could not mkdir %s", subdir)
-	}
-	err = os.WriteFile(path, data, 0700)
+func httpGetContext(ctx context.Context, url string) []byte {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		log.Fatalf("unable to write to %s", path)
+		log.Fatal(err)
 	}
-}
-
-func httpGet(url string) []byte {
-	resp, err := http.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -198,63 +803,94 @@ func httpGet(url string) []byte {
 	if resp.StatusCode > 299 {
 		log.Fatalf("HTTP %d", resp.StatusCode)
 	}
-	//
-	// Prevent us from getting rate-limited
-	//
-	time.Sleep(5000 * time.Millisecond)
 	return body
 }
 
-// This loadX stuff is rather repetitive, is there a way to avoid duplicating
-// it for each of Pull and Event?
-func loadEvents(repo string, issueNumber int) []Event {
-	var events []Event
-
-	jsonFilename := fmt.Sprintf("cache/%s/events/%d.json", repo, issueNumber)
-	data, err := readCache(jsonFilename)
-	if err == nil {
-		json.Unmarshal(data, &events)
-		return events
+// fetch decodes the JSON at url into v.
+func fetch(url string, v any) {
+	if err := json.Unmarshal(httpGet(url), v); err != nil {
+		log.Fatalf("JSON unmarshalling failed: %s", err)
 	}
+}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/issues/%d/events", repo, issueNumber)
-	log.Printf("cache miss, reading %s from the wire", url)
-	data = httpGet(url)
-
-	writeCache(jsonFilename, data)
+// fetchOptional is fetch for things we may not be allowed to see, or that
+// may not exist; it says whether it found anything.
+func fetchOptional(url string, v any) bool {
+	resp, err := client.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return false
+	case resp.StatusCode > 299:
+		log.Fatalf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		log.Fatalf("JSON unmarshalling failed: %s", err)
+	}
+	return true
+}
 
-	if err := json.Unmarshal(data, &events); err != nil {
+// fetchFinal is for things that can't change any more, so whatever we have
+// cached for them is good regardless of --ttl.
+func fetchFinal(url string, v any) {
+	ctx := context.WithValue(context.Background(), neverStale{}, true)
+	if err := json.Unmarshal(httpGetContext(ctx, url), v); err != nil {
 		log.Fatalf("JSON unmarshalling failed: %s", err)
 	}
+}
 
+func loadEvents(repo string, issueNumber int) []Event {
+	var events []Event
+	fetch(repoURL(repo, "/issues/%d/events", issueNumber), &events)
 	return events
 }
 
 func loadPulls(repo string, page int) []Pull {
 	var pulls []Pull
+	fetch(repoURL(repo, "/pulls?state=all&sort=%s&direction=desc&page=%d", listSort(), page), &pulls)
+	return pulls
+}
 
-	jsonFilename := fmt.Sprintf("cache/%s/pulls/%d.json", repo, page)
-	data, err := readCache(jsonFilename)
-	if err == nil {
-		json.Unmarshal(data, &pulls)
-		return pulls
-	}
+func loadIssues(repo string, page int) []Issue {
+	var issues []Issue
+	fetch(repoURL(repo, "/issues?state=all&page=%d", page), &issues)
+	return issues
+}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/pulls?state=all&page=%d", repo, page)
-	log.Printf("cache miss, reading %s from the wire", url)
+func loadReviews(repo string, pullNumber int) []Review {
+	var reviews []Review
+	fetch(repoURL(repo, "/pulls/%d/reviews", pullNumber), &reviews)
+	return reviews
+}
 
-	data = httpGet(url)
-	writeCache(jsonFilename, data)
+func loadPull(repo string, pullNumber int) Pull {
+	var pull Pull
+	fetch(repoURL(repo, "/pulls/%d", pullNumber), &pull)
+	return pull
+}
 
-	if err := json.Unmarshal(data, &pulls); err != nil {
-		log.Fatalf("JSON unmarshalling failed: %s", err)
+func whoMerged(repo string, pull Pull) User {
+	if pull.MergedBy != nil {
+		return *pull.MergedBy
 	}
 
-	return pulls
-}
-
-func whoMerged(repo string, issueNumber int) User {
-	for _, event := range loadEvents(repo, issueNumber) {
+	var events []Event
+	if pull.MergedAt != "" {
+		//
+		// Nothing can un-merge a PR, so there's no point revalidating.
+		//
+		fetchFinal(repoURL(repo, "/issues/%d/events", pull.Number), &events)
+	} else {
+		events = loadEvents(repo, pull.Number)
+	}
+	for _, event := range events {
 		if event.Event == "merged" {
 			return event.Actor
 		}
@@ -262,61 +898,581 @@ func whoMerged(repo string, issueNumber int) User {
 	return User{"nobody"}
 }
 
+var prefetch int
+
+var excludeDrafts bool
+
+// prefetchPulls returns a function that gets the repo's pages of PRs one at
+// a time, while up to lookahead of the following pages are fetched in the
+// background, so that waiting for the network overlaps with classifying.
+// The price is that we may fetch up to lookahead pages that we end up not
+// needing.  Call stop when done, so that the background fetching stops too.
+func prefetchPulls(repo string, lookahead int) (next func() []Pull, stop func()) {
+	if lookahead <= 0 {
+		page := 0
+		return func() []Pull {
+			page++
+			return loadPulls(repo, page)
+		}, func() {}
+	}
+
+	//
+	// The fetcher blocks on handing over a page, so besides the pages in the
+	// buffer, it's only ever working on one more.
+	//
+	pages := make(chan []Pull, lookahead-1)
+	quit := make(chan struct{})
+	go func() {
+		defer close(pages)
+		for page := 1; ; page++ {
+			pulls := loadPulls(repo, page)
+			select {
+			case pages <- pulls:
+			case <-quit:
+				return
+			}
+			if len(pulls) == 0 {
+				return
+			}
+		}
+	}()
+	return func() []Pull {
+			return <-pages
+		}, func() {
+			close(quit)
+		}
+}
+
+// mergedBy is whoMerged, but timed.
+func mergedBy(repo string, pull Pull) User {
+	defer timed(repo, phaseEvents, time.Now())
+	return whoMerged(repo, pull)
+}
+
+// reviewsOf is the reviews of the PR, asking the API unless a GraphQL lookup
+// already told us.
+func reviewsOf(repo string, pull Pull) []Review {
+	if pull.haveReviews {
+		return pull.reviews
+	}
+	defer timed(repo, phaseReviews, time.Now())
+	return loadReviews(repo, pull.Number)
+}
+
+// iReviewed says whether I reviewed the PR.
+func iReviewed(repo string, pull Pull) bool {
+	for _, r := range reviewsOf(repo, pull) {
+		if isMe(r.User.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOwnRepo(repo string) bool {
+	_, name := splitRepo(repo)
+	owner, _, _ := strings.Cut(name, "/")
+	return isMe(owner)
+}
+
 func parseTime(pull Pull) time.Time {
-	const format string = "2006-01-02T15:04:05Z"
-	parsedTime, err := time.Parse(format, pull.CreatedAt)
+	parsedTime, err := parseTimestamp(pull.CreatedAt)
 	if err != nil {
 		log.Fatalf("unable to parse time from %s", pull.CreatedAt)
 	}
 	return parsedTime
 }
 
-func main() {
-	var repos = os.Args[1:]
-	fmt.Println(header)
-	for _, repo := range repos {
-		var pulls []Pull
-		var done bool = false
-		var authored int = 0
-		var merged int = 0
-		for page := 1; !done; page++ {
-			pagePulls := loadPulls(repo, page)
-			if len(pagePulls) == 0 {
+// stringList is a flag that may be given more than once.
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ",")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
+
+func collectRepo(repo string, year int) RepoResult {
+	span := startPhase("collect", "repo", repo)
+	defer span.End()
+
+	result := RepoResult{Name: repo}
+	var done bool = false
+	seen := 0
+	next, stop := prefetchPulls(repo, prefetch)
+	defer stop()
+	for !done {
+		start := time.Now()
+		pagePulls := next()
+		timed(repo, phaseList, start)
+		if len(pagePulls) == 0 {
+			break
+		}
+
+		if useGraphQL {
+			start := time.Now()
+			lookupPulls(repo, year, pagePulls)
+			timed(repo, phaseGraphQL, start)
+		}
+
+		//
+		// We ask for the PRs newest first, so once a page reaches back past the
+		// start of the period, there's no point asking for the next one.  We
+		// still look at the whole page, though, instead of trusting the order
+		// within it.
+		//
+		oldest := sortTime(pagePulls[0])
+		for _, p := range pagePulls {
+			if ts := sortTime(p); ts.Before(oldest) {
+				oldest = ts
+			}
+			ts, ok := anchorTime(p)
+			if !ok || !inPeriod(ts, year) || (excludeDrafts && p.Draft) || !countsBase(repo, p) || ignored(repo, p.Number) {
+				continue
+			}
+			if sampling() && seen == sampleSize {
+				done = true
 				break
 			}
-			sort.Sort(PullList(pagePulls))
-
-			for _, p := range pagePulls {
-				ts := parseTime(p)
-				if ts.Year() > 2021 {
-					continue
-				} else if ts.Year() < 2021 {
-					done = true
-					break
-				}
-				p.Timestamp = ts.Format("2006-01-02")
-
-				//
-				// For each pull request, we need to work out what our contribution,
-				// if any, actually was.  Did we actually author the PR?  Or did we
-				// simply merge it?
-				//
-				if p.User.Login == "mpenkov" {
-					p.MyContribution = "authored"
-					authored++
-				} else if p.State == "closed" && whoMerged(repo, p.Number).Login == "mpenkov" {
-					p.MyContribution = "merged"
-					merged++
-				} else {
-					continue
-				}
-
-				pulls = append(pulls, p)
+			seen++
+			p.Timestamp = anchor(p)
+			if mentee := menteeOf(p); mentee != "" {
+				result.Mentored = append(result.Mentored, mentored(repo, p, mentee))
+			}
+
+			//
+			// For each pull request, we need to work out what our contribution,
+			// if any, actually was.  Did we actually author the PR?  Or did we
+			// simply merge it?  See rules.go.
+			//
+			if p.MyContribution = classify(repo, p); p.MyContribution == "" {
+				continue
+			}
+
+			if touchesPaths(repo, p) {
+				result.add(p)
 			}
 		}
+		first, _ := periodBounds(year)
+		done = done || oldest.Before(first)
+	}
+	//
+	// Late merges are a second trawl through the repo, which a preview can do
+	// without.
+	//
+	if lateMerges && matchOn == matchCreated && !sampling() {
+		collectLateMerges(repo, year, &result)
+	}
+	sort.Sort(PullList(result.Pulls))
+	return result
+}
 
-		if err := report.Execute(os.Stdout, RepoResult{repo, pulls, authored, merged}); err != nil {
+// add counts the PR towards its kind of contribution.
+func (result *RepoResult) add(p Pull) {
+	switch p.MyContribution {
+	case "authored":
+		result.Authored++
+	case "merged":
+		result.Merged++
+	case "reviewed":
+		result.Reviewed++
+	case "committed":
+		result.Committed++
+	}
+	//
+	// Whatever needs the description gets it from loadPull, so don't carry
+	// it around in the results, the state file and the report's data.
+	//
+	p.Body = ""
+	result.Pulls = append(result.Pulls, p)
+}
+
+// reportOptions are the flags that control what goes into a report, shared
+// by every command that produces one.
+type reportOptions struct {
+	year       *int
+	gitDirs    stringList
+	mapCommits *bool
+	excludeOwn *bool
+	onlyOwn    *bool
+	include    stringList
+	exclude    stringList
+	orgs       stringList
+	pick       *bool
+	// onRepo, if set, hears about each repo as soon as it's collected.
+	onRepo func(done int, total int, result RepoResult)
+}
+
+func addReportFlags(flags *flag.FlagSet) *reportOptions {
+	o := &reportOptions{}
+	o.year = flags.Int("year", defaultYear, "the year to report on")
+	flags.StringVar(&preset, "preset", "", "report on part of the year instead: q1 to q4, h1 or h2 of the --year fiscal year, or a fiscal year like fy2024")
+	flags.Var(&o.gitDirs, "git-dir", "a local clone to read commit stats from, as path or owner/repo=path (may be repeated)")
+	o.mapCommits = flags.Bool("map-commits", false, "attribute my commits to the PRs they landed through, for squash-merge repos")
+	o.excludeOwn = flags.Bool("exclude-own", false, "leave out repos that I own")
+	o.onlyOwn = flags.Bool("only-own", false, "only include repos that I own")
+	flags.Var(&o.include, "include", "only include repos matching this pattern, e.g. owner/* (may be repeated)")
+	flags.Var(&o.exclude, "exclude", "leave out repos matching this pattern (may be repeated)")
+	flags.Var(&o.orgs, "org", "also report on this organization's repos that were pushed to during the period (may be repeated)")
+	o.pick = flags.Bool("pick", false, "list the repos and ask which to drop before fetching anything")
+	flags.BoolVar(&openAtEnd, "open-at-end", false, "list my PRs and assigned issues still open at the end of the year")
+	flags.BoolVar(&resolvedIssues, "resolved", false, "count the issues closed by PRs I authored or merged")
+	flags.BoolVar(&assignedWork, "assigned", false, "list the issues and PRs assigned to me during the year that I didn't otherwise contribute to")
+	flags.BoolVar(&afterHours, "after-hours", false, "mark contributions made on weekends or outside working hours")
+	flags.BoolVar(&reviewDepth, "review-depth", false, "count the comments on my PRs, and those I left on PRs I reviewed")
+	flags.BoolVar(&reviewStats, "review-stats", false, "break my reviews down into approvals and change requests, and count review rounds")
+	flags.BoolVar(&mentionStats, "mentions", false, "count the issues and PRs that @mention me, and how often others cross-referenced my PRs")
+	flags.BoolVar(&supportWork, "support", false, "count my comments on issues that other people opened, as community support")
+	flags.BoolVar(&wikiEdits, "wiki", false, "count my edits to the repos' wikis, from clones of them kept in the cache directory")
+	flags.BoolVar(&signingStats, "signing", false, "count how many of my commits, and of the merges I did, Github verified the signatures of")
+	flags.IntVar(&hotspots, "hotspots", 0, "list this many files that my authored PRs changed most, per repo")
+	flags.BoolVar(&linkedWork, "linked", false, "group PRs in different repos that reference each other")
+	flags.Var(&projectOwners, "project", "add a section on the items I added, moved or closed on OWNER's project boards, or just board OWNER/NUMBER (may be repeated)")
+	flags.BoolVar(&gistActivity, "gists", false, "add a section on the gists I created or updated")
+	flags.BoolVar(&securityWork, "security", false, "add a section on security work: advisories I published and PRs labeled security")
+	flags.BoolVar(&expandDeps, "expand-deps", false, "list each dependency update I merged, instead of one row per repo")
+	flags.BoolVar(&showProfile, "profile", false, "put my avatar, name and bio at the top of the report")
+	flags.BoolVar(&gfmTitles, "gfm-titles", false, "render :emoji: shortcodes and inline code in PR titles like Github does")
+	flags.StringVar(&themeFile, "theme-file", "", "a CSS file that sets the report's custom properties, e.g. --accent: #c00;")
+	flags.StringVar(&palette, "palette", palette, "the colors for states and contributions: default, or colorblind")
+	flags.StringVar(&paper, "paper", "", "the paper size to print the HTML report on: a4 or letter")
+	flags.IntVar(&sampleSize, "sample", 0, "only look at the newest N PRs in each repo, for a quick preview of the report")
+	flags.StringVar(&columns, "columns", defaultColumns, "which columns the PR tables have, out of "+strings.Join(allColumns, ","))
+	return o
+}
+
+func (o *reportOptions) collect(args []string) []RepoResult {
+	if *o.excludeOwn && *o.onlyOwn {
+		log.Fatal("--exclude-own and --only-own don't make sense together")
+	}
+	checkColumns()
+	checkPaper()
+	checkPalette()
+	checkTheme()
+	checkWorkingHours()
+	checkPreset()
+
+	if len(o.orgs) > 0 {
+		args = discoverRepos(o.orgs, *o.year, args)
+	}
+	var repos []string
+	for _, repo := range args {
+		if (*o.excludeOwn && isOwnRepo(repo)) || (*o.onlyOwn && !isOwnRepo(repo)) {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	repos = o.pickRepos(repos)
+	validateRepos(repos)
+
+	clones := parseGitDirs(o.gitDirs, repos)
+
+	var results []RepoResult
+	for _, repo := range repos {
+		result := collectRepo(repo, *o.year)
+		if *o.mapCommits {
+			start := time.Now()
+			mapCommitsToPulls(repo, *o.year, &result)
+			timed(repo, phaseCommits, start)
+		}
+		if dir, ok := clones[repo]; ok {
+			start := time.Now()
+			result.Commits = gitStats(dir, *o.year)
+			loadAvatars(repo, result.Commits)
+			timed(repo, phaseGit, start)
+		}
+		if ticketsEnabled() {
+			linkTickets(result.Pulls)
+		}
+		if securityWork {
+			result.Advisories = loadAdvisories(repo, *o.year)
+		}
+		if openAtEnd {
+			result.OpenAtEnd = loadOpenAtEnd(repo, *o.year)
+		}
+		if resolvedIssues {
+			result.Resolved = loadResolved(repo, result.Pulls)
+		}
+		if assignedWork {
+			result.Assigned = loadAssigned(repo, *o.year, result.Pulls)
+		}
+		if reviewDepth {
+			result.Depth = loadReviewDepth(repo, result.Pulls)
+		}
+		if afterHours {
+			markAfterHours(&result)
+		}
+		if reviewStats {
+			result.Verdicts = loadReviewStats(repo, result.Pulls)
+		}
+		if mentionStats {
+			result.Mentions = loadMentions(repo, *o.year, result.Pulls)
+		}
+		if supportWork {
+			result.Support = loadSupport(repo, *o.year)
+		}
+		if wikiEdits {
+			result.Wiki = loadWikiEdits(repo, *o.year)
+		}
+		if signingStats {
+			result.Signing = loadSigningStats(repo, *o.year, result.Pulls)
+		}
+		if hotspots > 0 {
+			result.Hotspots = loadHotspots(repo, result.Pulls)
+		}
+		if linkedWork {
+			findReferences(repo, result.Pulls)
+		}
+		annotate(repo, result.Pulls)
+		categorize(repo, result.Pulls)
+		if showColumn("comments") || showColumn("size") {
+			loadDetails(repo, result.Pulls)
+		}
+		result.Score = score(result.Pulls)
+		results = append(results, result)
+		if o.onRepo != nil {
+			o.onRepo(len(results), len(repos), result)
+		}
+	}
+	return results
+}
+
+func reportMain(args []string) {
+	flags := flag.NewFlagSet("ghreview", flag.ExitOnError)
+	opts := addReportFlags(flags)
+	out := flags.String("out", "", "write the report here instead of to stdout; may have {user}, {year}, {period}, {repo}, {format} and {date} in it")
+	actions := flags.Bool("actions", false, "run as a Github Actions step: repo and token from the environment, report to a file, summary to the job")
+	commitTo := flags.String("commit-to", "", "also commit the report to owner/repo:path (Markdown if path ends in .md, HTML otherwise)")
+	reportURL := flags.String("report-url", "", "where the report will be published, for completion notifications")
+	flags.StringVar(&templatePath, "template", "", "render the report with this Go template instead (html/template if it ends in .html)")
+	flags.BoolVar(&jsonOutput, "json", false, "write the report as JSON for other tools (see pkg/ghreview) instead of HTML")
+	flags.StringVar(&formatList, "format", "", "render the report in these formats, e.g. html,markdown,json (default html, or what --json or --template say)")
+	flags.StringVar(&signKeyPath, "sign-key", "", "sign each report with this Ed25519 key (PEM), next to it as REPORT.minisig; check with ghreview verify")
+	flags.Var(&hooks, "hook", "run this shell command once the report is written, with it and the summary in the environment (may be repeated)")
+	flags.BoolVar(&socialCard, "card", false, "also draw a summary card (SVG) next to the report, for sharing by hand (needs --out)")
+	parseFlags(flags, args)
+	checkTemplate()
+	checkOut(*out)
+	checkFormats(*out)
+	checkCard(*out)
+	checkSignKey(*out)
+
+	args = flags.Args()
+	if *actions {
+		args = setupActions(args)
+		if *out == "" {
+			*out = defaultActionsOut
+		}
+	}
+	if isTerminal(os.Stderr) {
+		opts.onRepo = statusLine.progress
+	}
+	results := opts.collect(args)
+	statusLine.done()
+	year := opts.year
+
+	span := startPhase("render")
+	start := time.Now()
+	var written []string
+	for _, o := range outputs(*out, results, *year) {
+		w := os.Stdout
+		if o.path != "" {
+			w = create(o.path)
+		}
+		if err := renderers[o.format](w, o.results, *year); err != nil {
 			log.Fatal(err)
 		}
+		if socialCard && o.format == "html" {
+			writeCard(o.path, o.results, *year)
+		}
+		if o.path != "" {
+			//
+			// Closing is when a full disk shows up, and a truncated report
+			// mustn't get signed.
+			//
+			if err := w.Close(); err != nil {
+				log.Fatalf("unable to write %s: %s", o.path, err)
+			}
+			signReport(o.path, *year)
+		}
+		written = append(written, o.path)
+	}
+	timed(allRepos, phaseRender, start)
+	span.End()
+
+	if *actions {
+		writeStepSummary(results, *year)
+		writeActionsOutput(written)
+	}
+	if *commitTo != "" {
+		commitReport(*commitTo, results, *year)
+	}
+	notifyCompletion(results, *year, *reportURL, written)
+}
+
+func renderHTML(w io.Writer, results []RepoResult, year int) error {
+	fmt.Fprintln(w, header)
+	if err := writePrintStyle(w, year); err != nil {
+		return err
+	}
+	if err := writePalette(w); err != nil {
+		return err
+	}
+	if err := writeTheme(w); err != nil {
+		return err
+	}
+	if err := writeMeta(w, results, year); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, bodyStart)
+	if err := writeSampleNote(w); err != nil {
+		return err
+	}
+	if err := writeProfile(w, loadProfile(results)); err != nil {
+		return err
+	}
+	if len(config.Targets) > 0 {
+		if err := report.ExecuteTemplate(w, "goals", goals(results)); err != nil {
+			return err
+		}
+	}
+	if len(config.Groups) == 0 {
+		for _, result := range results {
+			if err := report.ExecuteTemplate(w, "repo", result); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, group := range groupResults(results) {
+			if err := report.ExecuteTemplate(w, "group", group); err != nil {
+				return err
+			}
+		}
+	}
+
+	if jiraEnabled() && config.Jira.GroupByEpic {
+		if err := report.ExecuteTemplate(w, "epics", epics(results)); err != nil {
+			return err
+		}
+	}
+	if grouped := groupTickets(results); len(grouped) > 0 {
+		if err := report.ExecuteTemplate(w, "tickets", grouped); err != nil {
+			return err
+		}
+	}
+	if kinds := workKinds(results); kinds != nil {
+		if err := report.ExecuteTemplate(w, "kinds", kinds); err != nil {
+			return err
+		}
+	}
+	if categoriesEnabled() {
+		if err := report.ExecuteTemplate(w, "categories", categoryBreakdown(results)); err != nil {
+			return err
+		}
+	}
+	if clusters := linkedClusters(results); linkedWork && clusters != nil {
+		if err := report.ExecuteTemplate(w, "linked", clusters); err != nil {
+			return err
+		}
+	}
+	for _, m := range mentoring(results) {
+		if err := report.ExecuteTemplate(w, "mentoring", m); err != nil {
+			return err
+		}
+	}
+	if work := securitySection(results); securityWork && (work.Advisories != nil || work.Pulls != nil) {
+		if err := report.ExecuteTemplate(w, "security", work); err != nil {
+			return err
+		}
+	}
+	if projectsEnabled() {
+		if err := report.ExecuteTemplate(w, "projects", loadProjectWork(year)); err != nil {
+			return err
+		}
+	}
+	if gistActivity {
+		if err := report.ExecuteTemplate(w, "gists", loadGists(results, year)); err != nil {
+			return err
+		}
+	}
+	if len(config.Weights) > 0 {
+		if err := report.ExecuteTemplate(w, "scores", monthlyScores(results, year)); err != nil {
+			return err
+		}
+	}
+	if err := writeData(w, results); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, footer)
+	return err
+}
+
+// browserMain takes over in the browser build; see wasm.go.
+var browserMain func()
+
+func main() {
+	if browserMain != nil {
+		browserMain()
+		return
+	}
+	defer finishTracing()
+	defer finishProfiling()
+	defer printTimings()
+	defer printUsage()
+	defer unlockCache()
+	log.SetOutput(statusLine)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "archive":
+			archiveMain(os.Args[2:])
+			return
+		case "export":
+			exportMain(os.Args[2:])
+			return
+		case "import":
+			importMain(os.Args[2:])
+			return
+		case "mirror":
+			mirrorMain(os.Args[2:])
+			return
+		case "watch":
+			watchMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		case "publish":
+			publishMain(os.Args[2:])
+			return
+		case "bench":
+			benchMain(os.Args[2:])
+			return
+		case "stale":
+			staleMain(os.Args[2:])
+			return
+		case "tui":
+			tuiMain(os.Args[2:])
+			return
+		case "verify":
+			verifyMain(os.Args[2:])
+			return
+		case "ratelimit":
+			ratelimitMain(os.Args[2:])
+			return
+		case "whoami":
+			whoamiMain(os.Args[2:])
+			return
+		case "version":
+			versionMain(os.Args[2:])
+			return
+		case "self-update":
+			selfUpdateMain(os.Args[2:])
+			return
+		}
 	}
+	reportMain(os.Args[1:])
 }
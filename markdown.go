@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+//
+// The Markdown rendering mirrors the HTML one, for places where HTML won't
+// do, like Github issues and Actions step summaries.
+//
+
+const markdownTempl string = `
+{{- define "repo" }}
+## {{ .Name }}
+{{ template "repo body" . }}
+{{- end }}
+
+{{- define "group" }}
+## {{ .Name }}
+
+{{ len .Repos }} repos: authored {{ .Authored }} and merged {{ .Merged }} contributions{{ if .Reviewed }}, and reviewed {{ .Reviewed }} more{{ end }}.
+{{ if .Score }}
+Activity score: {{ printf "%g" .Score }}
+{{ end }}
+{{- range .Repos }}
+### {{ .Name }}
+{{ template "repo body" . }}
+{{- end }}
+{{- end }}
+
+{{- define "repo body" }}
+Authored {{ .Authored }} and merged {{ .Merged }} contributions{{ if .Reviewed }}, and reviewed {{ .Reviewed }} more{{ end }}.
+{{ if .Score }}
+Activity score: {{ printf "%g" .Score }}
+{{ end }}
+{{- if or .Committed .Direct }}
+Landed commits via {{ .Committed }} other PRs, and pushed {{ .Direct }} commits directly.
+{{ end }}
+{{- with .Resolved }}
+Resolved {{ len . }} issues via PRs.
+{{ end }}
+{{- with .Mentions }}
+Was @mentioned in {{ .Threads }} issues and PRs by others, who also cross-referenced my PRs {{ .CrossReferences }} times.
+{{ end }}
+{{- with .Support }}
+Answered in {{ len .Threads }} issues opened by others, with {{ .Comments }} comments.
+{{ end }}
+{{- with .Wiki }}
+Made {{ .Edits }} edits to {{ .Pages }} wiki pages.
+{{ end }}
+{{- with .Assigned }}
+Was assigned {{ len . }} more issues and PRs.
+{{ end }}
+{{- if .AfterHours }}
+{{ .AfterHours }} of {{ len .Pulls }} contributions ({{ .AfterHoursShare }}) were on weekends or after hours.
+{{ end }}
+{{- with .Depth }}
+Got {{ .Received }} comments on {{ .Authored }} authored PRs ({{ .AverageReceived }} per PR), and left {{ .Given }} on {{ .Reviews }} PRs I reviewed ({{ .AverageGiven }} per PR).
+{{ end }}
+{{- with .Signing }}
+Github verified the signatures on {{ .VerifiedCommits }} of my {{ .Commits }} commits ({{ .CommitRatio }}){{ if gt .SignedCommits .VerifiedCommits }}, out of {{ .SignedCommits }} signed{{ end }}.{{ if .Merges }}  It verified {{ .VerifiedMerges }} of the {{ .Merges }} merges I did ({{ .MergeRatio }}).{{ end }}
+{{ end }}
+{{- with .Verdicts }}{{ if .Total }}
+Of my {{ .Total }} reviews, {{ .Approved }} approved ({{ .ApprovalRatio }}), {{ .ChangesRequested }} requested changes and {{ .Commented }} only commented.  Merged PRs took {{ .AverageRounds }} rounds of my reviews.
+{{ end }}{{ end }}
+{{- if .Pulls }}
+|
+{{- if column "number" }} # |{{ end }}
+{{- if column "timestamp" }} Timestamp |{{ end }}
+{{- if column "state" }} State |{{ end }}
+{{- if column "contribution" }} Contribution |{{ end }}
+{{- if column "title" }} Title |{{ end }}
+{{- if column "labels" }} Labels |{{ end }}
+{{- if column "milestone" }} Milestone |{{ end }}
+{{- if column "assignees" }} Assignees |{{ end }}
+{{- if column "comments" }} Comments |{{ end }}
+{{- if column "size" }} Size |{{ end }}
+{{- if column "category" }} Category |{{ end }}
+{{- if tickets }} Tickets |{{ end }}
+{{- if notes }} Notes |{{ end }}
+|
+{{- if column "number" }}---|{{ end }}
+{{- if column "timestamp" }}-----------|{{ end }}
+{{- if column "state" }}-------|{{ end }}
+{{- if column "contribution" }}--------------|{{ end }}
+{{- if column "title" }}-------|{{ end }}
+{{- if column "labels" }}--------|{{ end }}
+{{- if column "milestone" }}-----------|{{ end }}
+{{- if column "assignees" }}-----------|{{ end }}
+{{- if column "comments" }}----------|{{ end }}
+{{- if column "size" }}------|{{ end }}
+{{- if column "category" }}----------|{{ end }}
+{{- if tickets }}---------|{{ end }}
+{{- if notes }}-------|{{ end }}
+{{- range .TablePulls }}
+|
+{{- if column "number" }} [{{ .Number }}]({{ .HtmlUrl }}) |{{ end }}
+{{- if column "timestamp" }} {{ timestamp .Timestamp }}{{ if .AfterHours }} ☾{{ end }} |{{ end }}
+{{- if column "state" }} {{ .DisplayState }} |{{ end }}
+{{- if column "contribution" }} {{ .MyContribution }} |{{ end }}
+{{- if column "title" }} {{ cell .Title }} |{{ end }}
+{{- if column "labels" }} {{ cell .LabelNames }} |{{ end }}
+{{- if column "milestone" }} {{ with .Milestone }}{{ cell .Title }}{{ end }} |{{ end }}
+{{- if column "assignees" }} {{ .AssigneeLogins }} |{{ end }}
+{{- if column "comments" }} {{ .CommentCount }} |{{ end }}
+{{- if column "size" }} {{ .Size }} |{{ end }}
+{{- if column "category" }} {{ .Category }} |{{ end }}
+{{- if tickets }}{{ range .Tickets }} [{{ .Key }}]({{ .URL }}){{ end }} |{{ end }}
+{{- if notes }} {{ cell .Note }} |{{ end }}
+{{- end }}
+{{- with .DependencyUpdates }}
+| Merged {{ . }} dependency updates |
+{{- end }}
+{{ end }}
+{{- if .OpenAtEnd }}
+Still open at the end of the year:
+{{ range .OpenAtEnd }}
+- {{ .Kind }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }}
+{{- end }}
+{{ end }}
+{{- if .Resolved }}
+Issues resolved via PRs:
+{{ range .Resolved }}
+- [{{ .Label $.Name }}]({{ .HtmlUrl }}) {{ .Title }}, by #{{ .Via }}
+{{- end }}
+{{ end }}
+{{- with .Support }}
+Community support:
+{{ range .Threads }}
+- [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }}, by {{ .User.Login }} ({{ .Comments }} comments)
+{{- end }}
+{{ end }}
+{{- if .Assigned }}
+Assigned to me:
+{{ range .Assigned }}
+- {{ .Kind }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }}, assigned {{ timestamp .AssignedAt }}
+{{- end }}
+{{ end }}
+{{- if .Hotspots }}
+| File | PRs |
+|------|-----|
+{{- range .Hotspots }}
+| ` + "`{{ cell .Path }}`" + ` | {{ .Pulls }} |
+{{- end }}
+{{ end }}
+{{- if .Commits }}
+| Author | Commits | Files touched | Lines added | Lines removed |
+|--------|---------|---------------|-------------|---------------|
+{{- range .Commits }}
+| {{ if .AvatarUrl }}<img src="{{ .AvatarUrl }}" alt="" width="20" height="20"> {{ end }}{{ cell .Author }} | {{ .Commits }} | {{ .Files }} | {{ .Added }} | {{ .Removed }} |
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "goals" }}
+## Goals
+
+| Goal | Done | Complete |
+|------|------|----------|
+{{- range . }}
+| {{ .Name }} | {{ .Done }} of {{ .Target }} | {{ .Percent }}% |
+{{- end }}
+{{ end }}
+
+{{- define "epics" }}
+## Epics
+{{ range . }}
+### {{ if .URL }}[{{ .Key }}]({{ .URL }}){{ else }}{{ .Key }}{{ end }}
+{{ range .Pulls }}
+- {{ .Repo }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }}
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "tickets" }}
+## Tickets
+{{ range . }}
+### [{{ .Key }}]({{ .URL }})
+{{ range .Pulls }}
+- {{ .Repo }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }}
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "kinds" }}
+## Kinds of work
+
+| Kind | PRs | Share |
+|------|-----|-------|
+{{- range . }}
+| {{ .Kind }} | {{ .Count }} | {{ .Percent }}% |
+{{- end }}
+{{ end }}
+
+{{- define "categories" }}
+## Categories
+
+` + "```mermaid" + `
+pie
+{{- range . }}
+    "{{ .Name }}" : {{ .Count }}
+{{- end }}
+` + "```" + `
+
+| Category | PRs | Share |
+|----------|-----|-------|
+{{- range . }}
+| {{ cell .Name }} | {{ .Count }} | {{ .Percent }}% |
+{{- end }}
+{{ end }}
+
+{{- define "linked" }}
+## Linked work across repos
+{{ range . }}
+### {{ .RepoList }}
+{{ range .Pulls }}
+- {{ .Repo }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }} ({{ .MyContribution }})
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "mentoring" }}
+## Mentoring {{ .Mentee }}
+
+{{ .Mentee }} opened {{ .Opened }} PRs: I reviewed {{ .Reviewed }} and merged {{ .Merged }} of them.  {{ .Landed }} were merged, {{ .Closed }} closed without merging, and {{ .Open }} are still open.
+{{ if .Pulls }}
+| Repo | # | Timestamp | Title | Reviewed | Merged | Outcome |
+|------|---|-----------|-------|----------|--------|---------|
+{{- range .Pulls }}
+| {{ .Repo }} | [{{ .Number }}]({{ .HtmlUrl }}) | {{ timestamp .Timestamp }} | {{ cell .Title }} | {{ if .Reviewed }}yes{{ end }} | {{ if .Merged }}yes{{ end }} | {{ .Outcome }} |
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "security" }}
+## Security work
+{{ if .Advisories }}
+| Advisory | CVE | Severity | Published | Summary |
+|----------|-----|----------|-----------|---------|
+{{- range .Advisories }}
+| [{{ .GhsaID }}]({{ .HtmlUrl }}) | {{ .CveID }} | {{ .Severity }} | {{ timestamp .PublishedAt }} | {{ cell .Summary }} |
+{{- end }}
+{{ end }}
+{{- range .Pulls }}
+- {{ .Repo }} [#{{ .Number }}]({{ .HtmlUrl }}) {{ .Title }} ({{ .MyContribution }})
+{{- end }}
+{{ end }}
+
+{{- define "projects" }}
+## Project management
+
+Added {{ .Added }} items to project boards, moved {{ .Moved }} and closed {{ .Closed }}.
+{{ if .Items }}
+| Project | Item | Action | Status | Timestamp |
+|---------|------|--------|--------|-----------|
+{{- range .Items }}
+| [{{ cell .Project }}]({{ .ProjectURL }}) | {{ if .URL }}[{{ with .Ref }}{{ . }} {{ end }}{{ cell .Title }}]({{ .URL }}){{ else }}{{ cell .Title }}{{ end }} | {{ .Action }} | {{ cell .Status }} | {{ timestamp .Timestamp }} |
+{{- end }}
+{{ end }}
+{{- end }}
+
+{{- define "gists" }}
+## Gists
+
+Created {{ .Created }} gists and updated {{ .Updated }} more.
+{{ range .Gists }}
+- [{{ .Title }}]({{ .HtmlUrl }}) ({{ .Action }} {{ timestamp .When }})
+{{- end }}
+{{ end }}
+
+{{- define "scores" }}
+## Activity score by month
+
+| Month | Score |{{ if blackouts }} Away |{{ end }}
+|-------|-------|{{ if blackouts }}------|{{ end }}
+{{- range . }}
+| {{ .Month }} | {{ printf "%g" .Score }} |{{ if blackouts }} {{ cell .Away }} |{{ end }}
+{{- end }}
+{{ if blackouts }}
+Average: {{ activeAverage . }} that I wasn't away.
+{{ end }}
+{{- end }}
+`
+
+// reportHeading replaces the usual heading, for reports that aren't about a
+// year's contributions.
+var reportHeading string
+
+var markdownReport = template.Must(template.New("markdown").Funcs(template.FuncMap{"cell": markdownCell, "tickets": ticketsEnabled, "notes": notesEnabled, "timestamp": displayTime, "column": showColumn, "blackouts": blackoutsEnabled, "activeAverage": activeAverage}).Parse(markdownTempl))
+
+// markdownCell makes s safe to put inside a table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// markdownText makes s safe to put in running text, where it could
+// otherwise turn into emphasis, a link or HTML.
+func markdownText(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;").Replace(s)
+	return markdownCell(s)
+}
+
+func renderMarkdown(w io.Writer, results []RepoResult, year int) error {
+	heading := fmt.Sprintf("Contributions by %s in %s", user, periodName(year))
+	if reportHeading != "" {
+		heading = reportHeading
+	}
+	fmt.Fprintf(w, "# %s\n", previewTitle(heading))
+	if sampling() {
+		fmt.Fprintf(w, "\n> **%s**\n", sampleNote())
+	}
+	writeProfileMarkdown(w, loadProfile(results))
+	if len(config.Targets) > 0 {
+		if err := markdownReport.ExecuteTemplate(w, "goals", goals(results)); err != nil {
+			return err
+		}
+	}
+	if len(config.Groups) == 0 {
+		for _, result := range results {
+			if err := markdownReport.ExecuteTemplate(w, "repo", result); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, group := range groupResults(results) {
+			if err := markdownReport.ExecuteTemplate(w, "group", group); err != nil {
+				return err
+			}
+		}
+	}
+
+	if jiraEnabled() && config.Jira.GroupByEpic {
+		if err := markdownReport.ExecuteTemplate(w, "epics", epics(results)); err != nil {
+			return err
+		}
+	}
+	if grouped := groupTickets(results); len(grouped) > 0 {
+		if err := markdownReport.ExecuteTemplate(w, "tickets", grouped); err != nil {
+			return err
+		}
+	}
+	if kinds := workKinds(results); kinds != nil {
+		if err := markdownReport.ExecuteTemplate(w, "kinds", kinds); err != nil {
+			return err
+		}
+	}
+	if categoriesEnabled() {
+		if err := markdownReport.ExecuteTemplate(w, "categories", categoryBreakdown(results)); err != nil {
+			return err
+		}
+	}
+	if clusters := linkedClusters(results); linkedWork && clusters != nil {
+		if err := markdownReport.ExecuteTemplate(w, "linked", clusters); err != nil {
+			return err
+		}
+	}
+	for _, m := range mentoring(results) {
+		if err := markdownReport.ExecuteTemplate(w, "mentoring", m); err != nil {
+			return err
+		}
+	}
+	if work := securitySection(results); securityWork && (work.Advisories != nil || work.Pulls != nil) {
+		if err := markdownReport.ExecuteTemplate(w, "security", work); err != nil {
+			return err
+		}
+	}
+	if projectsEnabled() {
+		if err := markdownReport.ExecuteTemplate(w, "projects", loadProjectWork(year)); err != nil {
+			return err
+		}
+	}
+	if gistActivity {
+		if err := markdownReport.ExecuteTemplate(w, "gists", loadGists(results, year)); err != nil {
+			return err
+		}
+	}
+	if len(config.Weights) > 0 {
+		return markdownReport.ExecuteTemplate(w, "scores", monthlyScores(results, year))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+//
+// How often other people pull me into their work, or point at mine, says
+// something about impact that counting my own PRs doesn't.  With
+// --mentions, each repo counts the issues and PRs opened during the period
+// that @mention me (the search API counts threads, not each mention, which
+// is plenty for a rough measure), and the times somebody else
+// cross-referenced one of my authored PRs during it.
+//
+
+var mentionStats bool
+
+type TimelineEvent struct {
+	Event     string
+	CreatedAt string `json:"created_at"`
+	Actor     *User
+}
+
+type Mentions struct {
+	// Threads are the issues and PRs that mention me.
+	Threads int
+	// CrossReferences are the times others referenced my PRs.
+	CrossReferences int
+}
+
+func loadMentions(repo string, year int, pulls []Pull) *Mentions {
+	_, name := splitRepo(repo)
+	first, next := periodBounds(year)
+	created := fmt.Sprintf("%s..%s", first.Format(time.RFC3339), next.Add(-time.Second).Format(time.RFC3339))
+
+	m := &Mentions{}
+	for _, login := range myLogins() {
+		var found searchResult
+		fetch(searchURL(repo, fmt.Sprintf("repo:%s mentions:%s -author:%s created:%s", name, login, login, created), 1), &found)
+		m.Threads += found.TotalCount
+	}
+
+	for _, p := range pulls {
+		if p.MyContribution != "authored" {
+			continue
+		}
+		for page := 1; page <= commentsMaxPages; page++ {
+			var events []TimelineEvent
+			fetch(repoURL(repo, "/issues/%d/timeline?per_page=%d&page=%d", p.Number, commentsPageSize, page), &events)
+			for _, e := range events {
+				if e.Event != "cross-referenced" || e.Actor == nil || isMe(e.Actor.Login) {
+					continue
+				}
+				if ts, err := parseTimestamp(e.CreatedAt); err == nil && inPeriod(ts, year) {
+					m.CrossReferences++
+				}
+			}
+			if len(events) < commentsPageSize {
+				break
+			}
+		}
+	}
+	if m.Threads == 0 && m.CrossReferences == 0 {
+		return nil
+	}
+	return m
+}
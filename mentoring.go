@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+//
+// Mentoring shows up in the numbers as reviews and merges of somebody else's
+// PRs, mixed in with everybody else's.  With --mentee, we pick out that
+// person's PRs for the year, whether or not I touched them, and say how many
+// I reviewed or merged and how they turned out.
+//
+
+var mentees stringList
+
+type MenteePull struct {
+	Pull
+	Mentee   string
+	Reviewed bool
+	Merged   bool
+}
+
+type MenteeRow struct {
+	Repo string
+	MenteePull
+}
+
+type Mentoring struct {
+	Mentee string
+	// Opened counts the mentee's PRs, Reviewed and Merged the ones I
+	// reviewed and merged, and Landed, Closed and Open how they ended up.
+	Opened   int
+	Reviewed int
+	Merged   int
+	Landed   int
+	Closed   int
+	Open     int
+	Pulls    []MenteeRow
+}
+
+// menteeOf says which of the mentees, if any, authored the PR.
+func menteeOf(p Pull) string {
+	for _, mentee := range mentees {
+		for _, login := range loginsOf(mentee) {
+			if strings.EqualFold(login, p.User.Login) {
+				return mentee
+			}
+		}
+	}
+	return ""
+}
+
+func mentored(repo string, p Pull, mentee string) MenteePull {
+	mp := MenteePull{Pull: p, Mentee: mentee}
+	mp.Timestamp = anchor(p)
+	mp.Merged = p.MergedAt != "" && isMe(mergedBy(repo, p).Login)
+	mp.Reviewed = iReviewed(repo, p)
+	return mp
+}
+
+func (mp MenteePull) Outcome() string {
+	switch {
+	case mp.MergedAt != "":
+		return "merged"
+	case mp.State == "closed":
+		return "closed"
+	}
+	return "open"
+}
+
+func mentoring(results []RepoResult) []Mentoring {
+	byMentee := map[string]*Mentoring{}
+	for _, mentee := range mentees {
+		byMentee[mentee] = &Mentoring{Mentee: mentee}
+	}
+	for _, result := range results {
+		for _, mp := range result.Mentored {
+			m := byMentee[mp.Mentee]
+			if m == nil {
+				continue
+			}
+			m.Opened++
+			if mp.Reviewed {
+				m.Reviewed++
+			}
+			if mp.Merged {
+				m.Merged++
+			}
+			switch mp.Outcome() {
+			case "merged":
+				m.Landed++
+			case "closed":
+				m.Closed++
+			default:
+				m.Open++
+			}
+			m.Pulls = append(m.Pulls, MenteeRow{result.Name, mp})
+		}
+	}
+
+	var all []Mentoring
+	for _, mentee := range mentees {
+		m := byMentee[mentee]
+		sort.SliceStable(m.Pulls, func(i, j int) bool { return m.Pulls[i].Timestamp > m.Pulls[j].Timestamp })
+		all = append(all, *m)
+	}
+	return all
+}
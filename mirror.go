@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+//
+// Mirroring walks everything the API has to say about a repo: every page of
+// PRs and issues, and the events and reviews of each of them.  All of it
+// lands in the cache, so afterwards any report on that repo, for any user,
+// year or format, runs without a single API call.
+//
+
+func mirrorMain(args []string) {
+	var repos stringList
+	flags := flag.NewFlagSet("mirror", flag.ExitOnError)
+	flags.Var(&repos, "repo", "a repo to mirror, as owner/repo (may be repeated)")
+	parseFlags(flags, args)
+
+	repos = append(repos, flags.Args()...)
+	if len(repos) == 0 {
+		log.Fatal("usage: ghreview mirror --repo owner/repo...")
+	}
+	for _, repo := range repos {
+		mirrorRepo(repo)
+	}
+}
+
+func mirrorRepo(repo string) {
+	pulls := 0
+	for page := 1; ; page++ {
+		pagePulls := loadPulls(repo, page)
+		if len(pagePulls) == 0 {
+			break
+		}
+		for _, p := range pagePulls {
+			loadEvents(repo, p.Number)
+			loadReviews(repo, p.Number)
+		}
+		pulls += len(pagePulls)
+		log.Printf("%s: mirrored %d PRs so far", repo, pulls)
+	}
+
+	//
+	// The issues endpoint lists PRs too, and we already have those.
+	//
+	issues := 0
+	for page := 1; ; page++ {
+		pageIssues := loadIssues(repo, page)
+		if len(pageIssues) == 0 {
+			break
+		}
+		for _, i := range pageIssues {
+			if i.PullRequest == nil {
+				loadEvents(repo, i.Number)
+				issues++
+			}
+		}
+		log.Printf("%s: mirrored %d issues so far", repo, issues)
+	}
+
+	log.Printf("%s: mirrored %d PRs and %d issues", repo, pulls, issues)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//
+// The numbers never tell the whole story, so the notes file lets you attach
+// a sentence of context to a PR, which then shows up next to it in the
+// report.  It's YAML, keyed by PR like the ignore file:
+//
+//	acme/widgets#1204: this one fixed the big outage in March
+//	"acme/widgets#1300": "rolled out to every region, see the postmortem"
+//	acme/gadgets#77: |
+//	  took three attempts,
+//	  but we got there
+//
+// There's no YAML in the standard library, so we read just this much of it:
+// one mapping of plain, quoted or block scalars.
+//
+
+const defaultNotesPath string = "ghreview-notes.yaml"
+
+var notesPath string
+
+var annotations = map[string]string{}
+
+func notesEnabled() bool {
+	return len(annotations) > 0
+}
+
+func loadNotes(path string) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultNotesPath {
+		return
+	} else if err != nil {
+		log.Fatalf("unable to read %s: %s", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("unable to read %s: %s", path, err)
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			log.Fatalf("%s:%d: unexpected indentation", path, i+1)
+		}
+
+		key, value, err := splitMapping(line)
+		if err != nil {
+			log.Fatalf("%s:%d: %s", path, i+1, err)
+		}
+		if value == "|" || value == ">" || value == "|-" || value == ">-" {
+			var block []string
+			for i+1 < len(lines) && (strings.TrimSpace(lines[i+1]) == "" || strings.HasPrefix(lines[i+1], " ")) {
+				i++
+				block = append(block, strings.TrimSpace(lines[i]))
+			}
+			separator := "\n"
+			if value[0] == '>' {
+				separator = " "
+			}
+			value = strings.TrimSpace(strings.Join(block, separator))
+		} else if value, err = unquoteScalar(value); err != nil {
+			log.Fatalf("%s:%d: %s", path, i+1, err)
+		}
+
+		repo, number, ok := strings.Cut(key, "#")
+		if _, err := strconv.Atoi(number); !ok || err != nil || !strings.Contains(repo, "/") {
+			log.Fatalf("%s:%d: expected owner/repo#number, not %q", path, i+1, key)
+		}
+		annotations[strings.ToLower(key)] = value
+	}
+}
+
+// splitMapping splits a key: value line, where the key may be quoted.
+func splitMapping(line string) (string, string, error) {
+	var key, rest string
+	if line[0] == '"' || line[0] == '\'' {
+		end := strings.IndexByte(line[1:], line[0])
+		if end < 0 {
+			return "", "", errors.New("unterminated key")
+		}
+		key, rest = line[1:end+1], line[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", errors.New("expected : after the key")
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(line, ": ")
+		if i < 0 && strings.HasSuffix(line, ":") {
+			i = len(line) - 1
+		}
+		if i < 0 {
+			return "", "", errors.New("expected key: value")
+		}
+		key, rest = line[:i], line[i+1:]
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(rest), nil
+}
+
+func unquoteScalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("bad quoted string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("bad quoted string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// annotate attaches the notes for repo to its PRs.
+func annotate(repo string, pulls []Pull) {
+	if len(annotations) == 0 {
+		return
+	}
+	_, name := splitRepo(repo)
+	for i := range pulls {
+		suffix := "#" + strconv.Itoa(pulls[i].Number)
+		if note, ok := annotations[strings.ToLower(repo+suffix)]; ok {
+			pulls[i].Note = note
+		} else {
+			pulls[i].Note = annotations[strings.ToLower(name+suffix)]
+		}
+	}
+}
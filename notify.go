@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//
+// Once a run is done, we can let people know about it.  Anything that can
+// take a JSON POST works as a generic webhook; Matrix gets a native message
+// since its API wants a particular shape.
+//
+
+type Summary struct {
+	User      string
+	Year      int
+	Repos     int
+	Authored  int
+	Merged    int
+	Reviewed  int
+	Score     float64
+	ReportURL string `json:",omitempty"`
+	// Reports are the files the report was written to, if any.
+	Reports []string `json:",omitempty"`
+	// Sample is how many PRs per repo a --sample preview looked at.
+	Sample int `json:",omitempty"`
+	Usage  Usage
+	// Mentions and CrossReferences are there with --mentions.
+	Mentions        int `json:",omitempty"`
+	CrossReferences int `json:",omitempty"`
+	// Categories counts contributions by category, if there are any.
+	Categories map[string]int `json:",omitempty"`
+}
+
+type Notifier interface {
+	Notify(s Summary) error
+}
+
+type webhookNotifier struct {
+	url string
+}
+
+type matrixNotifier struct {
+	homeserver string
+	room       string
+	token      string
+}
+
+func summarize(results []RepoResult, year int, reportURL string) Summary {
+	s := Summary{User: user, Year: year, Repos: len(results), ReportURL: reportURL, Sample: sampleSize, Usage: currentUsage()}
+	for _, result := range results {
+		s.Authored += result.Authored
+		s.Merged += result.Merged
+		s.Reviewed += result.Reviewed
+		s.Score += result.Score
+		if m := result.Mentions; m != nil {
+			s.Mentions += m.Threads
+			s.CrossReferences += m.CrossReferences
+		}
+	}
+	if categoriesEnabled() {
+		s.Categories = map[string]int{}
+		for _, c := range categoryBreakdown(results) {
+			s.Categories[c.Name] = c.Count
+		}
+	}
+	return s
+}
+
+func (s Summary) String() string {
+	text := fmt.Sprintf("Contributions by %s in %d: authored %d and merged %d across %d repos.", s.User, s.Year, s.Authored, s.Merged, s.Repos)
+	if s.Reviewed > 0 {
+		text = fmt.Sprintf("Contributions by %s in %d: authored %d, merged %d and reviewed %d across %d repos.", s.User, s.Year, s.Authored, s.Merged, s.Reviewed, s.Repos)
+	}
+	if s.Sample > 0 {
+		text += fmt.Sprintf(" (A preview of the newest %s in each repo.)", plural(s.Sample, "PR", "PRs"))
+	}
+	if s.ReportURL != "" {
+		text += " " + s.ReportURL
+	}
+	return text
+}
+
+func notifiers() []Notifier {
+	var ns []Notifier
+	for _, u := range config.Notify.Webhooks {
+		ns = append(ns, webhookNotifier{u})
+	}
+	if m := config.Notify.Matrix; m != nil {
+		token := m.Token
+		if m.TokenEnv != "" {
+			token = os.Getenv(m.TokenEnv)
+		}
+		ns = append(ns, matrixNotifier{m.Homeserver, m.Room, token})
+	}
+	for _, command := range append(config.Notify.Commands, hooks...) {
+		ns = append(ns, commandNotifier{command})
+	}
+	return ns
+}
+
+// notifyCompletion tells everybody who wants to know.  By now the report
+// exists, so failing to notify isn't worth failing the run over.
+func notifyCompletion(results []RepoResult, year int, reportURL string, reports []string) {
+	s := summarize(results, year, reportURL)
+	for _, r := range reports {
+		if abs, err := filepath.Abs(r); err == nil && r != "" {
+			s.Reports = append(s.Reports, abs)
+		}
+	}
+	for _, n := range notifiers() {
+		if err := n.Notify(s); err != nil {
+			log.Printf("unable to send a notification: %s", err)
+		}
+	}
+}
+
+func postJSON(method string, url string, header http.Header, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: HTTP %d", method, url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (n webhookNotifier) Notify(s Summary) error {
+	return postJSON(http.MethodPost, n.url, nil, s)
+}
+
+func (n matrixNotifier) Notify(s Summary) error {
+	txn := fmt.Sprintf("ghreview-%d", time.Now().UnixNano())
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", n.homeserver, url.PathEscape(n.room), txn)
+	header := http.Header{"Authorization": {"Bearer " + n.token}}
+	return postJSON(http.MethodPut, endpoint, header, map[string]string{"msgtype": "m.notice", "body": s.String()})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+//
+// With --org, we report on an organization's repos without having to list
+// them.  Big orgs are mostly dormant repos, though, and crawling each of
+// those for PRs costs calls for nothing, so we only keep repos that somebody
+// pushed to since the start of the period.  Merging a PR pushes to its base,
+// so that catches almost everything; the exception is a review of a PR that
+// never got merged in an otherwise untouched repo.
+//
+// Each org is listed in its own goroutine.  Requests to one host still go out
+// one at a time (see hosts.go), so this mostly pays off across hosts, and
+// while we're waiting on the slowest org.
+//
+
+type orgRepo struct {
+	FullName string `json:"full_name"`
+	PushedAt string `json:"pushed_at"`
+}
+
+// listOrgRepos is the org's repos pushed to since the start of the period,
+// as we'd spell them on the command line.  The org may be spelled
+// host/org, like repos.
+func listOrgRepos(org string, year int) []string {
+	host := hostFor(defaultHost)
+	if name, login, ok := strings.Cut(org, "/"); ok {
+		host, org = hostFor(name), login
+	}
+	first, _ := periodBounds(year)
+
+	var active []string
+	for page := 1; ; page++ {
+		var repos []orgRepo
+		url := fmt.Sprintf("%s/orgs/%s/repos?type=all&sort=pushed&direction=desc&per_page=100&page=%d", host.API, org, page)
+		if !fetchOptional(url, &repos) {
+			log.Fatalf("--org %s: no such organization, or the token can't see it", org)
+		}
+
+		//
+		// The repos come most recently pushed first, so the first one pushed
+		// before the period means we've seen every one that wasn't.
+		//
+		for _, r := range repos {
+			ts, err := parseTimestamp(r.PushedAt)
+			if err != nil || ts.Before(first) {
+				return active
+			}
+			name := r.FullName
+			if host.Name != defaultHost {
+				name = host.Name + "/" + name
+			}
+			active = append(active, name)
+		}
+		if len(repos) < 100 {
+			return active
+		}
+	}
+}
+
+// discoverRepos lists every org's active repos at once, and adds them to
+// repos, leaving out any that are already there.
+func discoverRepos(orgs []string, year int, repos []string) []string {
+	found := make([]chan []string, len(orgs))
+	for i, org := range orgs {
+		found[i] = make(chan []string, 1)
+		go func(org string, out chan<- []string) {
+			out <- listOrgRepos(org, year)
+		}(org, found[i])
+	}
+
+	seen := map[string]bool{}
+	for _, repo := range repos {
+		seen[strings.ToLower(repo)] = true
+	}
+	for i, org := range orgs {
+		active := <-found[i]
+		log.Printf("%s has %s pushed to since the start of %s", org, plural(len(active), "repo", "repos"), periodName(year))
+		for _, repo := range active {
+			if !seen[strings.ToLower(repo)] {
+				seen[strings.ToLower(repo)] = true
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos
+}
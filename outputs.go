@@ -0,0 +1,191 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Pipelines that file reports away by user and year shouldn't need a
+// wrapper script to work out where each goes.  --out may have placeholders
+// in it, e.g. --out "reports/{user}/{year}/{repo}.{format}":
+//
+//	{user}    the user the report is on
+//	{year}    the --year
+//	{period}  the year, or the --preset's period, like Q1-FY2024
+//	{repo}    owner/repo (host/owner/repo off github.com)
+//	{format}  html, md, json, or the extension of the --template
+//	{date}    today, as 2006-01-02
+//
+// With {repo} in it, each repo gets a report of its own.
+//
+// One collection can come out in several formats at once, e.g. --format
+// html,markdown,json.  Without {format} in --out, each gets the extension of
+// its format instead of the one in --out.
+//
+
+var outPlaceholder = regexp.MustCompile(`\{([a-z]*)\}`)
+
+var outPlaceholders = map[string]bool{"user": true, "year": true, "period": true, "repo": true, "format": true, "date": true}
+
+func checkOut(out string) {
+	for _, m := range outPlaceholder.FindAllStringSubmatch(out, -1) {
+		if !outPlaceholders[m[1]] {
+			log.Fatalf("--out %s: unknown placeholder {%s}; try user, year, period, repo, format or date", out, m[1])
+		}
+	}
+}
+
+var formatList string
+
+var renderers = map[string]func(io.Writer, []RepoResult, int) error{
+	"html":     renderHTML,
+	"markdown": renderMarkdown,
+	"json":     renderJSON,
+	"template": renderCustom,
+}
+
+var formatAliases = map[string]string{"md": "markdown"}
+
+// outputFormats are the formats that --format, --json and --template ask
+// for, in order.
+var outputFormats []string
+
+func checkFormats(out string) {
+	list := formatList
+	switch {
+	case list != "":
+	case jsonOutput:
+		list = "json"
+	case templatePath != "":
+		list = "template"
+	default:
+		list = "html"
+	}
+	outputFormats = nil
+	seen := map[string]bool{}
+	for _, f := range strings.Split(list, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if alias, ok := formatAliases[f]; ok {
+			f = alias
+		}
+		if _, ok := renderers[f]; !ok {
+			log.Fatalf("--format %s: unknown format %q; try html, markdown, json or template", formatList, f)
+		}
+		if f == "template" && templatePath == "" {
+			log.Fatal("--format template needs --template")
+		}
+		if !seen[f] {
+			seen[f] = true
+			outputFormats = append(outputFormats, f)
+		}
+	}
+	if len(outputFormats) > 1 && out == "" {
+		log.Fatal("writing several formats needs --out, since they can't all go to stdout")
+	}
+}
+
+// extension is what files in format end in.  A template's output ends in
+// the template's own extension, unless another format ends in that too, as
+// with --format html,template and an HTML template, in which case it's
+// report.template.html.
+func extension(format string) string {
+	switch format {
+	case "markdown":
+		return "md"
+	case "template":
+		ext := strings.TrimPrefix(filepath.Ext(templatePath), ".")
+		if ext == "" {
+			return "template"
+		}
+		for _, f := range outputFormats {
+			if f != "template" && extension(f) == ext {
+				return "template." + ext
+			}
+		}
+		return ext
+	}
+	return format
+}
+
+func perRepoOutput(out string) bool {
+	return strings.Contains(out, "{repo}")
+}
+
+// outputPath fills in out's placeholders for the report on repo, which is
+// empty unless each repo gets its own.
+func outputPath(out string, year int, repo string, format string) string {
+	if out != "" && len(outputFormats) > 1 && !strings.Contains(out, "{format}") {
+		out = strings.TrimSuffix(out, filepath.Ext(out)) + "." + extension(format)
+	}
+	return outPlaceholder.ReplaceAllStringFunc(out, func(p string) string {
+		switch p {
+		case "{user}":
+			return user
+		case "{year}":
+			return strconv.Itoa(year)
+		case "{period}":
+			return strings.ReplaceAll(periodName(year), " ", "-")
+		case "{repo}":
+			return repo
+		case "{format}":
+			return extension(format)
+		case "{date}":
+			return time.Now().Format(time.DateOnly)
+		}
+		return p
+	})
+}
+
+type output struct {
+	path    string
+	format  string
+	results []RepoResult
+}
+
+// outputs are the reports to write: one per format, and with {repo} in
+// --out, one per repo as well.
+func outputs(out string, results []RepoResult, year int) []output {
+	var all []output
+	for _, format := range outputFormats {
+		if !perRepoOutput(out) {
+			all = append(all, output{outputPath(out, year, "", format), format, results})
+			continue
+		}
+		for _, result := range results {
+			all = append(all, output{outputPath(out, year, result.Name, format), format, []RepoResult{result}})
+		}
+	}
+	//
+	// One report overwriting another is never what was meant.
+	//
+	seen := map[string]string{}
+	for _, o := range all {
+		if o.path == "" {
+			continue
+		}
+		if format, ok := seen[o.path]; ok {
+			log.Fatalf("--out %s: the %s and %s reports would both go to %s", out, format, o.format, o.path)
+		}
+		seen[o.path] = o.format
+	}
+	return all
+}
+
+// create opens path for writing, making its directory if need be.
+func create(path string) *os.File {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("unable to create %s: %s", path, err)
+	}
+	return f
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+)
+
+//
+// States and contributions are told apart by color as well as by their
+// text, and the colors come from a palette, as custom properties named after
+// the classes (see theme.go).  The default one isn't kind to
+// color-blind readers, so there's also one built on the Okabe-Ito colors,
+// and the config can override any color, e.g.
+//
+//	"Colors": {"state-open": "#d55e00", "contribution-merged": "navy"}
+//
+
+var palettes = map[string]map[string]string{
+	"default": {
+		"state-open":                    "hsl(0, 90%, 50%)",
+		"state-closed":                  "hsl(240, 100%, 50%)",
+		"state-draft":                   "hsl(0, 0%, 50%)",
+		"contribution-authored":         "hsl(120, 80%, 40%)",
+		"contribution-merged":           "hsl(240, 100%, 50%)",
+		"contribution-reviewed":         "hsl(280, 60%, 45%)",
+		"contribution-committed":        "hsl(30, 90%, 40%)",
+		"contribution-review-requested": "hsl(0, 70%, 45%)",
+	},
+	"colorblind": {
+		"state-open":                    "#d55e00",
+		"state-closed":                  "#0072b2",
+		"state-draft":                   "#6e6e6e",
+		"contribution-authored":         "#009e73",
+		"contribution-merged":           "#0072b2",
+		"contribution-reviewed":         "#cc79a7",
+		"contribution-committed":        "#a06a00",
+		"contribution-review-requested": "#d55e00",
+	},
+}
+
+var palette string = "default"
+
+func checkPalette() {
+	if _, ok := palettes[palette]; !ok {
+		log.Fatalf("--palette must be default or colorblind, not %q", palette)
+	}
+	for class := range config.Colors {
+		if _, ok := palettes["default"][class]; !ok {
+			log.Fatalf("unknown class %q in Colors", class)
+		}
+	}
+}
+
+func writePalette(w io.Writer) error {
+	colors := map[string]string{}
+	for class, color := range palettes[palette] {
+		colors[class] = color
+	}
+	for class, color := range config.Colors {
+		colors[class] = color
+	}
+
+	var classes []string
+	for class := range colors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	var b strings.Builder
+	b.WriteString("<style>\n:root {\n")
+	for _, class := range classes {
+		fmt.Fprintf(&b, "    --%s: %s;\n", class, cssValue(colors[class]))
+	}
+	b.WriteString("}\n</style>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"path"
+	"strings"
+)
+
+//
+// In a monorepo, the whole repo is rarely what anybody works on.  With
+// --path, only PRs that change files under the given globs count.  Finding
+// out costs a call (or a few) per PR, so we only ask about PRs that would
+// otherwise count.
+//
+
+// The files endpoint stops at 3000 files, in pages of at most 100.
+const (
+	filesPageSize int = 100
+	filesMaxPages int = 30
+)
+
+var pathGlobs stringList
+
+type PullFile struct {
+	Filename string
+}
+
+func loadFiles(repo string, pull Pull) []string {
+	var names []string
+	for page := 1; page <= filesMaxPages; page++ {
+		var files []PullFile
+		url := repoURL(repo, "/pulls/%d/files?per_page=%d&page=%d", pull.Number, filesPageSize, page)
+		if pull.State == "closed" {
+			//
+			// What a closed PR changed is set in stone.
+			//
+			fetchFinal(url, &files)
+		} else {
+			fetch(url, &files)
+		}
+		for _, f := range files {
+			names = append(names, f.Filename)
+		}
+		if len(files) < filesPageSize {
+			break
+		}
+	}
+	return names
+}
+
+func touchesPaths(repo string, pull Pull) bool {
+	if len(pathGlobs) == 0 {
+		return true
+	}
+	for _, name := range loadFiles(repo, pull) {
+		for _, glob := range pathGlobs {
+			if matchGlob(glob, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob is path.Match, except that ** matches any number of directories,
+// including none.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil {
+			log.Fatalf("bad --path pattern: %s", err)
+		}
+		if !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
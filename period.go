@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Reports follow the calendar year unless --preset says otherwise: q1 to q4
+// and h1 or h2 are the quarters and halves of the --year fiscal year, and
+// fy2024 is the whole of that fiscal year.  Fiscal years start in January
+// unless the config sets FiscalYearStart to another month, and are named by
+// the calendar year they end in, so with "FiscalYearStart": 7, FY2024 runs
+// from July 2023 to June 2024, and q1 of it is July to September 2023.
+//
+
+var preset string
+
+var presetPattern = regexp.MustCompile(`^(?:(q[1-4]|h[12])|fy(\d{4}))$`)
+
+func checkPreset() {
+	if preset != "" && !presetPattern.MatchString(strings.ToLower(preset)) {
+		log.Fatalf("--preset must be q1 to q4, h1, h2 or a fiscal year like fy2024, not %q", preset)
+	}
+	if m := config.FiscalYearStart; m < 0 || m > 12 {
+		log.Fatalf("FiscalYearStart must be a month from 1 to 12, not %d", m)
+	}
+}
+
+// fiscalYearStart is when the fiscal year named year starts.
+func fiscalYearStart(year int) time.Time {
+	month := config.FiscalYearStart
+	if month <= 1 {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, location())
+	}
+	return time.Date(year-1, time.Month(month), 1, 0, 0, 0, 0, location())
+}
+
+// periodBounds is when the report for year starts, and when the next one
+// would.
+func periodBounds(year int) (time.Time, time.Time) {
+	m := presetPattern.FindStringSubmatch(strings.ToLower(preset))
+	switch {
+	case m == nil:
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, location())
+		return start, start.AddDate(1, 0, 0)
+	case m[2] != "":
+		fy, _ := strconv.Atoi(m[2])
+		start := fiscalYearStart(fy)
+		return start, start.AddDate(1, 0, 0)
+	}
+	n := int(m[1][1] - '0')
+	months := 3
+	if m[1][0] == 'h' {
+		months = 6
+	}
+	start := fiscalYearStart(year).AddDate(0, (n-1)*months, 0)
+	return start, start.AddDate(0, months, 0)
+}
+
+func inPeriod(t time.Time, year int) bool {
+	start, end := periodBounds(year)
+	return !t.Before(start) && t.Before(end)
+}
+
+// periodName is what we call the period in headings: the year, or the
+// preset and its fiscal year.
+func periodName(year int) string {
+	m := presetPattern.FindStringSubmatch(strings.ToLower(preset))
+	switch {
+	case m == nil:
+		return strconv.Itoa(year)
+	case m[2] != "":
+		return "FY" + m[2]
+	}
+	return fmt.Sprintf("%s FY%d", strings.ToUpper(m[1]), year)
+}
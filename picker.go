@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//
+// Long repo lists pick up noise: forks, experiments, archived things.
+// Instead of editing the list, you can narrow it with --include and --exclude
+// patterns (owner/* style, like groups), and with --pick go through what's
+// left and drop repos by number before we fetch anything.
+//
+
+func matchesAny(patterns []string, repo string) bool {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, repo)
+		if err != nil {
+			log.Fatalf("bad repo pattern %q: %s", pattern, err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *reportOptions) pickRepos(repos []string) []string {
+	var kept []string
+	for _, repo := range repos {
+		if len(o.include) > 0 && !matchesAny(o.include, repo) {
+			continue
+		}
+		if matchesAny(o.exclude, repo) {
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	if !*o.pick || len(kept) == 0 {
+		return kept
+	}
+
+	for i, repo := range kept {
+		fmt.Fprintf(os.Stderr, "%3d  %s\n", i+1, repo)
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, "drop which? (e.g. 2,5-7; enter keeps them all) ")
+		if !in.Scan() {
+			return kept
+		}
+		drop, err := parseSelection(in.Text(), len(kept))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		var picked []string
+		for i, repo := range kept {
+			if !drop[i+1] {
+				picked = append(picked, repo)
+			}
+		}
+		return picked
+	}
+}
+
+// parseSelection reads a list of numbers and ranges from 1 to n.
+func parseSelection(text string, n int) (map[int]bool, error) {
+	selected := map[int]bool{}
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(first)
+		to := from
+		if err == nil && isRange {
+			to, err = strconv.Atoi(last)
+		}
+		if err != nil || from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("%q isn't a number or range between 1 and %d", part, n)
+		}
+		for i := from; i <= to; i++ {
+			selected[i] = true
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,34 @@
+package ghreview_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mpenkov/ghreview/pkg/ghreview"
+)
+
+func ExampleCommandCollector() {
+	collector := ghreview.CommandCollector{Year: 2021, Args: []string{"--reviews"}}
+	report, err := collector.Collect(context.Background(), []string{"owner/repo"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, repo := range report.Repos {
+		fmt.Printf("%s: authored %d, merged %d, reviewed %d\n", repo.Repo, repo.Authored, repo.Merged, repo.Reviewed)
+	}
+}
+
+func ExampleDecode() {
+	f, err := os.Open("report.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	report, err := ghreview.Decode(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ghreview.JSONRenderer{}.Render(os.Stdout, report)
+}
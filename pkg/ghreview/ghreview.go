@@ -0,0 +1,137 @@
+// Package ghreview is the stable face of ghreview, for tools that want to
+// build on its collection engine instead of scraping its HTML.
+//
+// The types here only ever grow: fields are added, never renamed or
+// removed, within a major version of the module.  Reports travel as JSON,
+// which is what `ghreview --json` writes, so a Report decoded by one
+// version reads the same in the next.
+//
+// The engine itself runs as the ghreview command, with its cache, config
+// and rate limiting; CommandCollector drives it.
+package ghreview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// SchemaVersion is the version of the JSON that Reports travel as.
+const SchemaVersion = 1
+
+// A Contribution is a PR, and what I did with it.
+type Contribution struct {
+	Repo   string
+	Number int
+	URL    string
+	Title  string
+	Author string
+	// State is open, closed or draft.
+	State string
+	// Kind is authored, merged, reviewed, or whatever else the config's
+	// rules call it.
+	Kind string
+	// Timestamp is when the contribution happened, in RFC 3339.
+	Timestamp string
+	Labels    []string `json:",omitempty"`
+	Category  string   `json:",omitempty"`
+	Note      string   `json:",omitempty"`
+}
+
+// A RepoResult is everything about one repo.
+type RepoResult struct {
+	Repo          string
+	Authored      int
+	Merged        int
+	Reviewed      int
+	Score         float64        `json:",omitempty"`
+	Contributions []Contribution `json:",omitempty"`
+}
+
+// A Report is the contributions of one user over one period.
+type Report struct {
+	SchemaVersion int
+	User          string
+	// Period is a year, like 2021, or a --preset like Q1 FY2024.
+	Period string
+	// Sample is how many PRs per repo a preview looked at; 0 means all of
+	// them.
+	Sample int `json:",omitempty"`
+	Repos  []RepoResult
+}
+
+// A Collector gathers a Report on the given repos.
+type Collector interface {
+	Collect(ctx context.Context, repos []string) (*Report, error)
+}
+
+// A Renderer writes a Report in some format.
+type Renderer interface {
+	Render(w io.Writer, report *Report) error
+}
+
+// CommandCollector collects by running the ghreview command.
+type CommandCollector struct {
+	// Path is the ghreview executable; by default, ghreview on the PATH.
+	Path string
+	// Year is the year to report on; by default, the command's default.
+	Year int
+	// Args are any other flags, e.g. --reviews or --preset q1.
+	Args []string
+}
+
+func (c CommandCollector) Collect(ctx context.Context, repos []string) (*Report, error) {
+	path := c.Path
+	if path == "" {
+		path = "ghreview"
+	}
+	args := []string{"--json"}
+	if c.Year != 0 {
+		args = append(args, "--year", strconv.Itoa(c.Year))
+	}
+	args = append(args, c.Args...)
+	args = append(args, repos...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", path, err, lastLine(stderr.Bytes()))
+	}
+	return Decode(&stdout)
+}
+
+// Decode reads a Report as written by `ghreview --json`.
+func Decode(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+	if report.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("the report is in schema %d, but this package only knows up to %d", report.SchemaVersion, SchemaVersion)
+	}
+	return &report, nil
+}
+
+// JSONRenderer writes Reports the way `ghreview --json` does, so that
+// Decode can read them back.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func lastLine(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if i := bytes.LastIndexByte(b, '\n'); i >= 0 {
+		b = b[i+1:]
+	}
+	return string(b)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+//
+// Reports end up printed for review packets, so the HTML has a print
+// stylesheet: no link colors or browser-only controls, nothing cut off, each
+// repo starting on a new page, and whose report it is and for when in the
+// page margins.  --paper sets the page size; otherwise it's the printer's.
+//
+
+const printStyle string = `<style>
+@page {
+    %s
+    margin: 2cm 1.5cm;
+    @top-left {
+        content: "%s";
+        font: 9pt sans-serif;
+    }
+    @bottom-right {
+        content: "page " counter(page) " of " counter(pages);
+        font: 9pt sans-serif;
+    }
+}
+
+@media print {
+    a {
+        color: inherit;
+        text-decoration: none;
+    }
+    div.toolbar {
+        display: none;
+    }
+    h1 {
+        break-before: page;
+    }
+    main > section:first-of-type h1 {
+        break-before: avoid;
+    }
+    h2, tr {
+        break-inside: avoid;
+    }
+    h2 {
+        break-after: avoid;
+    }
+    td {
+        white-space: normal;
+        max-width: none;
+    }
+}
+</style>`
+
+var paper string
+
+var paperSizes = map[string]string{"a4": "A4", "letter": "letter"}
+
+func checkPaper() {
+	if _, ok := paperSizes[paper]; paper != "" && !ok {
+		log.Fatalf("--paper must be a4 or letter, not %q", paper)
+	}
+}
+
+func writePrintStyle(w io.Writer, year int) error {
+	size := ""
+	if s, ok := paperSizes[paper]; ok {
+		size = "size: " + s + ";"
+	}
+	title := fmt.Sprintf("Contributions by %s in %s", user, periodName(year))
+	if reportHeading != "" {
+		title = reportHeading
+	}
+	title = previewTitle(title)
+	title = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(title)
+	_, err := fmt.Fprintf(w, printStyle+"\n", size, title)
+	return err
+}
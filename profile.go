@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+)
+
+//
+// Where tracing tells us which repos and requests are slow, profiles tell us
+// what ghreview itself is spending its time and memory on.
+//
+
+var cpuProfile string
+
+var memProfile string
+
+var cpuProfileFile *os.File
+
+func startProfiling() {
+	if cpuProfile == "" {
+		return
+	}
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		log.Fatalf("unable to create %s: %s", cpuProfile, err)
+	}
+	if err := rpprof.StartCPUProfile(f); err != nil {
+		log.Fatalf("unable to start profiling: %s", err)
+	}
+	cpuProfileFile = f
+}
+
+func finishProfiling() {
+	if cpuProfileFile != nil {
+		rpprof.StopCPUProfile()
+		cpuProfileFile.Close()
+	}
+	if memProfile == "" {
+		return
+	}
+	f, err := os.Create(memProfile)
+	if err != nil {
+		log.Fatalf("unable to create %s: %s", memProfile, err)
+	}
+	defer f.Close()
+	runtime.GC()
+	if err := rpprof.WriteHeapProfile(f); err != nil {
+		log.Fatalf("unable to write %s: %s", memProfile, err)
+	}
+}
+
+// servePprof serves the usual /debug/pprof endpoints on an address of their
+// own, which is best kept to localhost.
+func servePprof(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	log.Printf("serving pprof on http://%s/debug/pprof/", addr)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
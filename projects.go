@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Tech leads spend a good part of the year on project boards, which no PR
+// count shows.  With --project OWNER (every board of an org or user) or
+// --project OWNER/NUMBER (just the one), we go through the boards' items
+// over GraphQL (either may have a host/ in front, like repos) and add a
+// project management section: the items I added to a
+// board during the period, and the ones whose status I last set during it.
+// Setting the status to something like Done counts as closing the item.
+//
+// Projects v2 doesn't keep a history we can read, so a status that somebody
+// else changed after me only counts for them, and items that were deleted
+// from a board are gone.
+//
+
+var projectOwners stringList
+
+var doneStatus = regexp.MustCompile(`(?i)^(done|closed|complete[d]?|shipped|released)$`)
+
+type ProjectActivity struct {
+	Project    string
+	ProjectURL string
+	Title      string
+	URL        string
+	// Ref is owner/repo#N for issues and PRs, and empty for drafts.
+	Ref string
+	// Action is added, moved or closed.
+	Action string
+	// Status is what I set the status to, if I did.
+	Status    string
+	Timestamp string
+}
+
+type ProjectWork struct {
+	Added  int
+	Moved  int
+	Closed int
+	Items  []ProjectActivity
+}
+
+type projectV2 struct {
+	Number int
+	Title  string
+	Url    string
+}
+
+type projectItem struct {
+	CreatedAt string
+	Creator   *User
+	Content   *struct {
+		Title      string
+		Url        string
+		Number     int
+		Repository *struct {
+			NameWithOwner string
+		}
+	}
+	Status *struct {
+		Name      string
+		UpdatedAt string
+		Creator   *User
+	}
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   string
+}
+
+const projectsQuery string = `query($owner: String!, $cursor: String) { repositoryOwner(login: $owner) { ... on ProjectV2Owner { projectsV2(first: 20, after: $cursor) { pageInfo { hasNextPage endCursor } nodes { number title url } } } } }`
+
+const projectQuery string = `query($owner: String!, $number: Int!) { repositoryOwner(login: $owner) { ... on ProjectV2Owner { projectV2(number: $number) { number title url } } } }`
+
+const projectItemsQuery string = `query($owner: String!, $number: Int!, $cursor: String) { repositoryOwner(login: $owner) { ... on ProjectV2Owner { projectV2(number: $number) { items(first: 100, after: $cursor) { pageInfo { hasNextPage endCursor } nodes { createdAt creator { login } content { ... on Issue { title url number repository { nameWithOwner } } ... on PullRequest { title url number repository { nameWithOwner } } ... on DraftIssue { title } } status: fieldValueByName(name: "Status") { ... on ProjectV2ItemFieldSingleSelectValue { name updatedAt creator { login } } } } } } } } }`
+
+func projectsEnabled() bool {
+	return len(projectOwners) > 0
+}
+
+// parseProject splits --project spec into its host, owner, and board
+// number, if there is one.
+func parseProject(spec string) (*Host, string, int) {
+	parts := strings.Split(spec, "/")
+	host := hostFor(defaultHost)
+	if _, err := strconv.Atoi(parts[len(parts)-1]); (len(parts) == 2 && err != nil) || len(parts) == 3 {
+		host, parts = hostFor(parts[0]), parts[1:]
+	}
+	switch len(parts) {
+	case 1:
+		return host, parts[0], 0
+	case 2:
+		if n, err := strconv.Atoi(parts[1]); err == nil {
+			return host, parts[0], n
+		}
+	}
+	log.Fatalf("expected --project OWNER or OWNER/NUMBER, got %q", spec)
+	return nil, "", 0
+}
+
+// listProjects is the boards that --project spec stands for.
+func listProjects(host *Host, owner string, n int, spec string) []projectV2 {
+	if n != 0 {
+		var data struct {
+			RepositoryOwner *struct {
+				ProjectV2 *projectV2
+			}
+		}
+		cachedGraphQL(host, projectQuery, map[string]any{"owner": owner, "number": n}, &data)
+		if data.RepositoryOwner == nil || data.RepositoryOwner.ProjectV2 == nil {
+			log.Fatalf("--project %s: no such project, or the token can't see it", spec)
+		}
+		return []projectV2{*data.RepositoryOwner.ProjectV2}
+	}
+
+	var projects []projectV2
+	var cursor any
+	for {
+		var data struct {
+			RepositoryOwner *struct {
+				ProjectsV2 struct {
+					PageInfo pageInfo
+					Nodes    []projectV2
+				}
+			}
+		}
+		cachedGraphQL(host, projectsQuery, map[string]any{"owner": owner, "cursor": cursor}, &data)
+		if data.RepositoryOwner == nil {
+			log.Fatalf("--project %s: no such organization or user", spec)
+		}
+		page := data.RepositoryOwner.ProjectsV2
+		projects = append(projects, page.Nodes...)
+		if !page.PageInfo.HasNextPage {
+			return projects
+		}
+		cursor = page.PageInfo.EndCursor
+	}
+}
+
+func loadProjectItems(host *Host, owner string, number int) []projectItem {
+	var items []projectItem
+	var cursor any
+	for {
+		var data struct {
+			RepositoryOwner *struct {
+				ProjectV2 *struct {
+					Items struct {
+						PageInfo pageInfo
+						Nodes    []projectItem
+					}
+				}
+			}
+		}
+		cachedGraphQL(host, projectItemsQuery, map[string]any{"owner": owner, "number": number, "cursor": cursor}, &data)
+		if data.RepositoryOwner == nil || data.RepositoryOwner.ProjectV2 == nil {
+			return items
+		}
+		page := data.RepositoryOwner.ProjectV2.Items
+		items = append(items, page.Nodes...)
+		if !page.PageInfo.HasNextPage {
+			return items
+		}
+		cursor = page.PageInfo.EndCursor
+	}
+}
+
+// mine says whether who is me, doing something during the period.
+func mine(who *User, timestamp string, year int) bool {
+	if who == nil || !isMe(who.Login) {
+		return false
+	}
+	ts, err := parseTimestamp(timestamp)
+	return err == nil && inPeriod(ts, year)
+}
+
+func loadProjectWork(year int) *ProjectWork {
+	work := &ProjectWork{}
+	for _, spec := range projectOwners {
+		host, owner, n := parseProject(spec)
+		if host.Token == "" {
+			log.Fatalf("--project needs a token for %s", host.Name)
+		}
+		for _, project := range listProjects(host, owner, n, spec) {
+			for _, item := range loadProjectItems(host, owner, project.Number) {
+				activity := ProjectActivity{Project: project.Title, ProjectURL: project.Url}
+				if c := item.Content; c != nil {
+					activity.Title, activity.URL = c.Title, c.Url
+					if c.Repository != nil {
+						activity.Ref = fmt.Sprintf("%s#%d", c.Repository.NameWithOwner, c.Number)
+					}
+				}
+
+				//
+				// What I did to the item last is what we show, but adding it
+				// and moving it both count.
+				//
+				if mine(item.Creator, item.CreatedAt, year) {
+					activity.Action, activity.Timestamp = "added", item.CreatedAt
+					work.Added++
+				}
+				if s := item.Status; s != nil && mine(s.Creator, s.UpdatedAt, year) {
+					activity.Action, activity.Status, activity.Timestamp = "moved", s.Name, s.UpdatedAt
+					if doneStatus.MatchString(s.Name) {
+						activity.Action = "closed"
+						work.Closed++
+					} else {
+						work.Moved++
+					}
+				}
+				if activity.Action != "" {
+					work.Items = append(work.Items, activity)
+				}
+			}
+		}
+	}
+	sort.SliceStable(work.Items, func(i, j int) bool { return work.Items[i].Timestamp > work.Items[j].Timestamp })
+	return work
+}
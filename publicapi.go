@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+
+	"github.com/mpenkov/ghreview/pkg/ghreview"
+)
+
+//
+// With --json, the report comes out as the public ghreview.Report instead of
+// HTML, for other tools to build on; see pkg/ghreview.  Our own types are
+// free to change, so this is the one place that has to keep up with them.
+//
+
+var jsonOutput bool
+
+func publicReport(results []RepoResult, year int) *ghreview.Report {
+	report := &ghreview.Report{SchemaVersion: ghreview.SchemaVersion, User: user, Period: periodName(year), Sample: sampleSize}
+	for _, result := range results {
+		repo := ghreview.RepoResult{
+			Repo:     result.Name,
+			Authored: result.Authored,
+			Merged:   result.Merged,
+			Reviewed: result.Reviewed,
+			Score:    result.Score,
+		}
+		for _, p := range result.Pulls {
+			var labels []string
+			for _, l := range p.Labels {
+				labels = append(labels, l.Name)
+			}
+			repo.Contributions = append(repo.Contributions, ghreview.Contribution{
+				Repo:      result.Name,
+				Number:    p.Number,
+				URL:       p.HtmlUrl,
+				Title:     p.Title,
+				Author:    p.User.Login,
+				State:     p.DisplayState(),
+				Kind:      p.MyContribution,
+				Timestamp: p.Timestamp,
+				Labels:    labels,
+				Category:  p.Category,
+				Note:      p.Note,
+			})
+		}
+		report.Repos = append(report.Repos, repo)
+	}
+	return report
+}
+
+func renderJSON(w io.Writer, results []RepoResult, year int) error {
+	return ghreview.JSONRenderer{}.Render(w, publicReport(results, year))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+//
+// Publishing puts the Markdown report in front of a team, as an issue or a
+// discussion in some "weekly updates" repo.  We look for an earlier one with
+// the same title and update it rather than opening a new one each time.
+//
+
+const publishLabel string = "ghreview"
+
+func publishMain(args []string) {
+	flags := flag.NewFlagSet("publish", flag.ExitOnError)
+	issueRepo := flags.String("issue", "", "publish as an issue in this repo")
+	discussionRepo := flags.String("discussion", "", "publish as a discussion in this repo")
+	category := flags.String("category", "General", "the discussion category to publish in")
+	pin := flags.Bool("pin", true, "pin the issue")
+	opts := addReportFlags(flags)
+	parseFlags(flags, args)
+
+	if (*issueRepo == "") == (*discussionRepo == "") {
+		log.Fatal("usage: ghreview publish (--issue owner/repo | --discussion owner/repo) owner/repo...")
+	}
+
+	results := opts.collect(flags.Args())
+	var body bytes.Buffer
+	if err := renderMarkdown(&body, results, *opts.year); err != nil {
+		log.Fatal(err)
+	}
+	title := fmt.Sprintf("Contributions by %s in %s", user, periodName(*opts.year))
+
+	var url string
+	if *issueRepo != "" {
+		url = publishIssue(*issueRepo, title, body.String(), *pin)
+	} else {
+		url = publishDiscussion(*discussionRepo, *category, title, body.String())
+	}
+	notifyCompletion(results, *opts.year, url, nil)
+}
+
+func publishIssue(repo string, title string, body string, pin bool) string {
+	var existing []Issue
+	apiRequest(http.MethodGet, repoURL(repo, "/issues?state=open&labels=%s&per_page=100", publishLabel), nil, &existing)
+
+	var issue Issue
+	found := false
+	for _, i := range existing {
+		if i.Title == title {
+			issue, found = i, true
+			break
+		}
+	}
+
+	if found {
+		apiRequest(http.MethodPatch, repoURL(repo, "/issues/%d", issue.Number), map[string]any{"body": body}, &issue)
+		log.Printf("updated %s", issue.HtmlUrl)
+	} else {
+		apiRequest(http.MethodPost, repoURL(repo, "/issues"), map[string]any{
+			"title":  title,
+			"body":   body,
+			"labels": []string{publishLabel},
+		}, &issue)
+		log.Printf("created %s", issue.HtmlUrl)
+	}
+
+	if pin {
+		//
+		// Only the GraphQL API knows how to pin things.
+		//
+		host, _ := splitRepo(repo)
+		graphql(host, `mutation($id: ID!) { pinIssue(input: {issueId: $id}) { issue { id } } }`, map[string]any{"id": issue.NodeID}, nil)
+	}
+	return issue.HtmlUrl
+}
+
+func publishDiscussion(repo string, category string, title string, body string) string {
+	host, name := splitRepo(repo)
+	owner, repoName := splitOwner(name)
+
+	var found struct {
+		Repository struct {
+			ID                   string
+			DiscussionCategories struct {
+				Nodes []struct {
+					ID   string
+					Name string
+				}
+			}
+			Discussions struct {
+				Nodes []struct {
+					ID    string
+					Title string
+					URL   string
+				}
+			}
+		}
+	}
+	graphql(host, `query($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) {
+			id
+			discussionCategories(first: 50) { nodes { id name } }
+			discussions(first: 100, orderBy: {field: CREATED_AT, direction: DESC}) { nodes { id title url } }
+		}
+	}`, map[string]any{"owner": owner, "name": repoName}, &found)
+
+	for _, d := range found.Repository.Discussions.Nodes {
+		if d.Title == title {
+			graphql(host, `mutation($id: ID!, $body: String!) {
+				updateDiscussion(input: {discussionId: $id, body: $body}) { discussion { id } }
+			}`, map[string]any{"id": d.ID, "body": body}, nil)
+			log.Printf("updated %s", d.URL)
+			return d.URL
+		}
+	}
+
+	categoryID := ""
+	for _, c := range found.Repository.DiscussionCategories.Nodes {
+		if c.Name == category {
+			categoryID = c.ID
+		}
+	}
+	if categoryID == "" {
+		log.Fatalf("%s has no discussion category called %q", repo, category)
+	}
+
+	var created struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				URL string
+			}
+		}
+	}
+	graphql(host, `mutation($repo: ID!, $category: ID!, $title: String!, $body: String!) {
+		createDiscussion(input: {repositoryId: $repo, categoryId: $category, title: $title, body: $body}) { discussion { url } }
+	}`, map[string]any{"repo": found.Repository.ID, "category": categoryID, "title": title, "body": body}, &created)
+	log.Printf("created %s", created.CreateDiscussion.Discussion.URL)
+	return created.CreateDiscussion.Discussion.URL
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+//
+// A big run can eat a whole hour's quota, so `ghreview ratelimit` shows how
+// much is left on each host we have credentials for, and when it resets,
+// before you start one.  Asking doesn't count against the quota.
+//
+
+type rateLimits struct {
+	Resources map[string]struct {
+		Limit     int
+		Used      int
+		Remaining int
+		Reset     int64
+	}
+}
+
+// The quotas that matter to us, in the order we show them.
+var rateResources = []string{"core", "search", "graphql"}
+
+func ratelimitMain(args []string) {
+	flags := flag.NewFlagSet("ratelimit", flag.ExitOnError)
+	var only stringList
+	flags.Var(&only, "host", "only show this host, e.g. github.example.com (may be repeated)")
+	parseFlags(flags, args)
+
+	names := []string(only)
+	if len(names) == 0 {
+		names = configuredHosts()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "host\tquota\tremaining\tlimit\tresets")
+	for _, name := range names {
+		host := hostFor(name)
+		auth := "anonymous"
+		if host.Token != "" {
+			auth = "token"
+		}
+
+		var limits rateLimits
+		if !apiLookup(host.API+"/rate_limit", &limits) {
+			fmt.Fprintf(w, "%s (%s)\t-\tnot rate limited\t\t\n", name, auth)
+			continue
+		}
+		for _, resource := range rateResources {
+			r, ok := limits.Resources[resource]
+			if !ok {
+				continue
+			}
+			reset := time.Unix(r.Reset, 0)
+			fmt.Fprintf(w, "%s (%s)\t%s\t%d\t%d\t%s (in %s)\n", name, auth, resource, r.Remaining, r.Limit,
+				reset.In(location()).Format("15:04:05"), time.Until(reset).Round(time.Second))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
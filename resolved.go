@@ -0,0 +1,92 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//
+// A PR count says little on its own: one PR may close five issues and
+// another none.  With --resolved, we read the descriptions of the PRs I
+// authored or merged for the closing keywords Github understands ("Fixes
+// #12", "closes owner/repo#34") and count the issues they closed.  Github
+// only closes issues when the PR lands, so we only look at merged PRs, and
+// only count issues that are actually closed now.
+//
+
+var resolvedIssues bool
+
+var closingPattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+([\w.-]+/[\w.-]+)?#(\d+)\b`)
+
+type ResolvedIssue struct {
+	Repo    string
+	Number  int
+	Title   string
+	HtmlUrl string `json:"html_url"`
+	// Via is the PR that closed it.
+	Via int
+}
+
+// closingRefs are the issues that body says it closes, as repos and
+// numbers.  References without a repo are to repo itself.
+func closingRefs(repo string, body string) []ResolvedIssue {
+	host, _ := splitRepo(repo)
+	var refs []ResolvedIssue
+	for _, m := range closingPattern.FindAllStringSubmatch(body, -1) {
+		number, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		target := repo
+		if m[1] != "" {
+			target = m[1]
+			if host.Name != defaultHost {
+				target = host.Name + "/" + m[1]
+			}
+		}
+		refs = append(refs, ResolvedIssue{Repo: target, Number: number})
+	}
+	return refs
+}
+
+func loadResolved(repo string, pulls []Pull) []ResolvedIssue {
+	var resolved []ResolvedIssue
+	seen := map[string]bool{}
+	for _, p := range pulls {
+		if p.MergedAt == "" || (p.MyContribution != "authored" && p.MyContribution != "merged") {
+			continue
+		}
+
+		full := loadPull(repo, p.Number)
+		for _, ref := range closingRefs(repo, full.Body) {
+			key := strings.ToLower(ref.Repo) + "#" + strconv.Itoa(ref.Number)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			var issue Issue
+			if !fetchOptional(repoURL(ref.Repo, "/issues/%d", ref.Number), &issue) {
+				continue
+			}
+			if issue.PullRequest != nil || issue.State != "closed" {
+				continue
+			}
+			ref.Title = issue.Title
+			ref.HtmlUrl = issue.HtmlUrl
+			ref.Via = p.Number
+			resolved = append(resolved, ref)
+		}
+	}
+	return resolved
+}
+
+// Label is how the issue is referred to from the repo it was resolved in.
+func (r ResolvedIssue) Label(repo string) string {
+	if strings.EqualFold(r.Repo, repo) {
+		return "#" + strconv.Itoa(r.Number)
+	}
+	_, name := splitRepo(r.Repo)
+	return name + "#" + strconv.Itoa(r.Number)
+}
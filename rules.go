@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+//
+// What counts as my contribution to a PR is decided by rules, tried in
+// order until one matches.  The built-in ones say I authored the PRs I
+// opened, merged the ones I merged, and (with --reviews) reviewed the ones I
+// reviewed.  The config can add more after those, each a contribution and
+// the conditions that must all hold for it, e.g.
+//
+//	"Rules": [
+//	    {"Contribution": "assigned", "When": ["assignee", "state:merged"]},
+//	    {"Contribution": "discussed", "When": ["commenter", "label:rfc"]}
+//	]
+//
+// The conditions are:
+//
+//	author      I opened the PR
+//	merger      I merged it
+//	reviewer    I reviewed it
+//	assignee    I'm one of its assignees
+//	requested   my review is still requested
+//	commenter   I commented on it, inline or in the conversation
+//	label:NAME  it has the label NAME
+//	state:S     it's open, closed, merged or draft
+//	base:REF    it targets the branch REF
+//
+// Conditions that need more API calls (merger, reviewer, commenter) are only
+// checked when the cheaper ones before them hold, so put those first.
+//
+
+type RuleConfig struct {
+	Contribution string
+	When         []string
+}
+
+type condition func(repo string, p Pull) bool
+
+type rule struct {
+	contribution string
+	conditions   []condition
+}
+
+var rules []rule
+
+func builtinRules() []RuleConfig {
+	builtins := []RuleConfig{
+		{"authored", []string{"author"}},
+		{"merged", []string{"merger"}},
+	}
+	if countReviews {
+		builtins = append(builtins, RuleConfig{"reviewed", []string{"reviewer"}})
+	}
+	return builtins
+}
+
+func compileRules() {
+	if rules != nil {
+		return
+	}
+	rules = []rule{}
+	for _, rc := range append(builtinRules(), config.Rules...) {
+		if rc.Contribution == "" || len(rc.When) == 0 {
+			log.Fatalf("rules need a Contribution and at least one condition, got %+v", rc)
+		}
+		r := rule{contribution: rc.Contribution}
+		for _, when := range rc.When {
+			r.conditions = append(r.conditions, compileCondition(when))
+		}
+		rules = append(rules, r)
+	}
+}
+
+func compileCondition(when string) condition {
+	name, arg, _ := strings.Cut(when, ":")
+	switch name {
+	case "author":
+		return func(repo string, p Pull) bool { return isMe(p.User.Login) }
+	case "merger":
+		return func(repo string, p Pull) bool { return p.State == "closed" && isMe(mergedBy(repo, p).Login) }
+	case "reviewer":
+		return iReviewed
+	case "assignee":
+		return func(repo string, p Pull) bool { return anyMe(p.Assignees) }
+	case "requested":
+		return func(repo string, p Pull) bool { return anyMe(p.RequestedReviewers) }
+	case "commenter":
+		return func(repo string, p Pull) bool {
+			for _, c := range loadComments(repo, p) {
+				if isMe(c.User.Login) {
+					return true
+				}
+			}
+			return false
+		}
+	case "label":
+		return func(repo string, p Pull) bool {
+			for _, l := range p.Labels {
+				if strings.EqualFold(l.Name, arg) {
+					return true
+				}
+			}
+			return false
+		}
+	case "state":
+		switch arg {
+		case "merged":
+			return func(repo string, p Pull) bool { return p.MergedAt != "" }
+		case "open", "closed", "draft":
+			return func(repo string, p Pull) bool { return p.DisplayState() == arg }
+		}
+	case "base":
+		return func(repo string, p Pull) bool { return p.Base.Ref == arg }
+	}
+	log.Fatalf("unknown rule condition %q", when)
+	return nil
+}
+
+func anyMe(users []User) bool {
+	for _, u := range users {
+		if isMe(u.Login) {
+			return true
+		}
+	}
+	return false
+}
+
+// classify is my contribution to the PR, or "" if it isn't one.
+func classify(repo string, p Pull) string {
+	compileRules()
+	for _, r := range rules {
+		matched := true
+		for _, c := range r.conditions {
+			if !c(repo, p) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r.contribution
+		}
+	}
+	return ""
+}
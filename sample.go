@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+//
+// A full run can take hours, which is a long time to wait to find out that a
+// flag or a template was wrong.  With --sample N, we only look at the first N
+// PRs of the period in each repo (the newest, since that's the order we ask
+// for them in), and say loudly everywhere that the report is only a preview.
+//
+
+var sampleSize int
+
+func sampling() bool {
+	return sampleSize > 0
+}
+
+// sampleNote says what the report leaves out, or nothing for a full report.
+func sampleNote() string {
+	if !sampling() {
+		return ""
+	}
+	return fmt.Sprintf("This is a preview: it only looks at the newest %s in each repo.", plural(sampleSize, "PR", "PRs"))
+}
+
+// previewTitle marks a title as a preview's.
+func previewTitle(title string) string {
+	if !sampling() {
+		return title
+	}
+	return title + " (preview)"
+}
+
+func writeSampleNote(w io.Writer) error {
+	if !sampling() {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "<p class=\"preview\" role=\"note\"><strong>%s</strong></p>\n", sampleNote())
+	return err
+}
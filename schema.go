@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//
+// What goes in the cache has changed shape before, and will again.  The
+// cache records which schema it's in under schemaKey, and each entry carries
+// the schema it was written with, so an upgrade never mixes up shapes:
+//
+//  1. raw JSON bodies under owner/repo/events/N.json and owner/repo/pulls/N.json
+//  2. whole HTTP responses, see httpcache.go
+//  3. the same, under file names that are safe on Windows, see filenames.go
+//
+// Older caches get migrated the first time we see them.  Entries from any
+// other schema than ours are treated as misses, and refetched.
+//
+
+const cacheSchema int = 3
+
+const schemaKey string = "schema"
+
+const schemaHeader string = "X-Ghreview-Schema"
+
+func checkSchema() {
+	data, err := cache.Get(schemaKey)
+	if err != nil && err != errCacheMiss {
+		log.Fatalf("unable to read the cache schema: %s", err)
+	}
+
+	fc, isFile := backend().(fileCache)
+	if err == errCacheMiss {
+		if isFile {
+			migrateLegacy(fc.dir)
+			renameUnsafe(fc)
+		}
+	} else {
+		if isSealed(data) {
+			log.Fatalf("the cache %s is encrypted, use --encrypt", cacheURL)
+		}
+		schema, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			log.Fatalf("unable to make sense of the cache schema %q", data)
+		}
+		if schema > cacheSchema {
+			log.Fatalf("the cache was written by a newer ghreview (schema %d, we know %d); upgrade, or use a different --cache", schema, cacheSchema)
+		}
+		if schema == cacheSchema {
+			return
+		}
+		if schema == 2 && isFile {
+			renameUnsafe(fc)
+		} else {
+			log.Printf("the cache is in schema %d, entries will be refetched as needed", schema)
+		}
+	}
+
+	if err := cache.Put(schemaKey, []byte(strconv.Itoa(cacheSchema))); err != nil {
+		log.Fatalf("unable to write the cache schema: %s", err)
+	}
+}
+
+// compatibleEntry says whether a cached response is in a shape we
+// understand.  Entries from before we stamped them are in schema 2, and
+// schema 3 only moved files around, so those are fine too.
+func compatibleEntry(resp *http.Response) bool {
+	stamp := resp.Header.Get(schemaHeader)
+	if stamp == "" {
+		return true
+	}
+	schema, err := strconv.Atoi(stamp)
+	return err == nil && schema >= 2 && schema <= cacheSchema
+}
+
+// migrateLegacy turns the raw JSON bodies of schema 1 into responses under
+// the keys we'd look them up by now.  Schema 1 only ever talked to
+// github.com, anonymously, which anyone may see, so we file them under
+// whatever credentials we have for github.com now.
+func migrateLegacy(dir string) {
+	migrated := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 4 || (parts[2] != "events" && parts[2] != "pulls") {
+			return nil
+		}
+		number, err := strconv.Atoi(strings.TrimSuffix(parts[3], ".json"))
+		if err != nil {
+			return nil
+		}
+
+		repo := parts[0] + "/" + parts[1]
+		url := repoURL(repo, "/issues/%d/events", number)
+		if parts[2] == "pulls" {
+			url = repoURL(repo, "/pulls?state=all&sort=created&direction=desc&page=%d", number)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		migrateEntry(url, data)
+		os.Remove(path)
+		migrated++
+		return nil
+	})
+	if migrated > 0 {
+		log.Printf("migrated %d cache entries from schema 1", migrated)
+	}
+}
+
+func migrateEntry(url string, body []byte) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Authorization", hostFor(defaultHost).authorization())
+
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}
+	if _, err := storeResponse(cacheKey(req), resp); err != nil {
+		log.Fatalf("unable to migrate %s: %s", url, err)
+	}
+}
+
+// renameUnsafe moves the files of a schema 2 cache to where schema 3 looks
+// for them.
+func renameUnsafe(fc fileCache) {
+	renamed := 0
+	filepath.WalkDir(fc.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(fc.dir, path)
+		target := fc.path(filepath.ToSlash(rel))
+		if target == path {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			log.Fatalf("unable to migrate %s: %s", path, err)
+		}
+		if err := os.Rename(path, target); err != nil {
+			log.Fatalf("unable to migrate %s: %s", path, err)
+		}
+		renamed++
+		return nil
+	})
+	if renamed > 0 {
+		log.Printf("renamed %d cache entries to Windows-safe names", renamed)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+//
+// Some teams want one number they can compare, so each kind of contribution
+// can be given a weight in the config, e.g. authored=3, merged=2.  Kinds
+// without a weight count for nothing.
+//
+
+type MonthScore struct {
+	Month string
+	Score float64
+	// Away names the blackouts during the month, and AwayAll says they
+	// covered all of it.
+	Away    string
+	AwayAll bool
+}
+
+func score(pulls []Pull) float64 {
+	var total float64
+	for _, p := range pulls {
+		total += config.Weights[p.MyContribution]
+	}
+	return total
+}
+
+func monthlyScores(results []RepoResult, year int) []MonthScore {
+	var months []MonthScore
+	start, end := periodBounds(year)
+	for m := start; m.Before(end); m = m.AddDate(0, 1, 0) {
+		months = append(months, MonthScore{Month: m.Format("2006-01")})
+	}
+
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			for i := range months {
+				if ts, err := parseTimestamp(p.Timestamp); err == nil && ts.Format("2006-01") == months[i].Month {
+					months[i].Score += config.Weights[p.MyContribution]
+				}
+			}
+		}
+	}
+	markAway(months)
+	return months
+}
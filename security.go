@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+)
+
+//
+// Security work tends to be invisible by design, so with --security the
+// report calls it out: advisories I published for the repo during the year,
+// and PRs with a security label.  Listing a repo's advisories needs a token
+// that can see them; without one, we just find none.
+//
+
+var securityWork bool
+
+type Advisory struct {
+	GhsaID      string `json:"ghsa_id"`
+	CveID       string `json:"cve_id"`
+	HtmlUrl     string `json:"html_url"`
+	Summary     string
+	Severity    string
+	PublishedAt string `json:"published_at"`
+	Publisher   *User
+	Author      *User
+}
+
+type SecurityPull struct {
+	Repo string
+	Pull
+}
+
+type SecurityWork struct {
+	Advisories []Advisory
+	Pulls      []SecurityPull
+}
+
+func loadAdvisories(repo string, year int) []Advisory {
+	var all []Advisory
+	if !fetchOptional(repoURL(repo, "/security-advisories?state=published&sort=published&direction=desc&per_page=100"), &all) {
+		return nil
+	}
+	var mine []Advisory
+	for _, a := range all {
+		ts, err := parseTimestamp(a.PublishedAt)
+		if err != nil || !inPeriod(ts, year) {
+			continue
+		}
+		if (a.Publisher != nil && isMe(a.Publisher.Login)) || (a.Author != nil && isMe(a.Author.Login)) {
+			mine = append(mine, a)
+		}
+	}
+	return mine
+}
+
+func isSecurityPull(p Pull) bool {
+	for _, label := range p.Labels {
+		if strings.Contains(strings.ToLower(label.Name), "security") {
+			return true
+		}
+	}
+	return false
+}
+
+func securitySection(results []RepoResult) SecurityWork {
+	var work SecurityWork
+	for _, result := range results {
+		work.Advisories = append(work.Advisories, result.Advisories...)
+		for _, p := range result.Pulls {
+			if isSecurityPull(p) {
+				work.Pulls = append(work.Pulls, SecurityPull{result.Name, p})
+			}
+		}
+	}
+	return work
+}
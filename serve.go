@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// In daemon mode we collect once, keep the results in memory, and serve the
+// report over HTTP.  Instead of polling, Github tells us about new activity
+// via webhooks, and we apply it to the results as it arrives.  The results
+// are saved to a state file after every change, so a restart doesn't have to
+// collect everything all over again.
+//
+
+const webhookSecretEnv string = "GHREVIEW_WEBHOOK_SECRET"
+
+type daemon struct {
+	mu        sync.Mutex
+	year      int
+	results   []RepoResult
+	statePath string
+	secret    []byte
+}
+
+type pullRequestEvent struct {
+	Action      string
+	PullRequest struct {
+		Pull
+		Merged bool
+	} `json:"pull_request"`
+	// Review is only in pull_request_review events.
+	Review     *Review
+	Repository struct {
+		FullName string `json:"full_name"`
+	}
+}
+
+func serveMain(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "where to listen")
+	year := flags.Int("year", time.Now().Year(), "the year to report on")
+	statePath := flags.String("state", "ghreview-state.json", "where to keep the collected results between restarts")
+	pprofAddr := flags.String("pprof", "", "also serve pprof on this address, e.g. localhost:6060")
+	parseFlags(flags, args)
+
+	repos := flags.Args()
+	if len(repos) == 0 {
+		log.Fatal("usage: ghreview serve [--addr host:port] owner/repo...")
+	}
+
+	secret := os.Getenv(webhookSecretEnv)
+	if secret == "" {
+		log.Printf("%s is not set, webhooks are disabled", webhookSecretEnv)
+	}
+
+	d := &daemon{year: *year, statePath: *statePath, secret: []byte(secret)}
+	if !d.load(repos) {
+		for _, repo := range repos {
+			result := collectRepo(repo, *year)
+			result.Score = score(result.Pulls)
+			d.results = append(d.results, result)
+		}
+		d.save()
+	}
+
+	if *pprofAddr != "" {
+		go servePprof(*pprofAddr)
+	}
+
+	//
+	// Use our own mux, because importing net/http/pprof puts its handlers on
+	// the default one, and those shouldn't be reachable by whoever can send us
+	// webhooks.
+	//
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.serveReport)
+	mux.HandleFunc("/webhook", d.serveWebhook)
+	log.Printf("serving the report on http://%s/", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+// load reads the state file, as long as it covers the repos we were asked
+// to serve.
+func (d *daemon) load(repos []string) bool {
+	data, err := os.ReadFile(d.statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	} else if err != nil {
+		log.Fatalf("unable to read %s: %s", d.statePath, err)
+	}
+	if err := json.Unmarshal(data, &d.results); err != nil {
+		log.Fatalf("unable to parse %s: %s", d.statePath, err)
+	}
+
+	if len(d.results) != len(repos) {
+		return false
+	}
+	for i, result := range d.results {
+		if result.Name != repos[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *daemon) save() {
+	data, err := json.Marshal(d.results)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(d.statePath, data, 0600); err != nil {
+		log.Fatalf("unable to write %s: %s", d.statePath, err)
+	}
+}
+
+func (d *daemon) serveReport(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := renderHTML(w, d.results, d.year); err != nil {
+		log.Printf("unable to render the report: %s", err)
+	}
+}
+
+func (d *daemon) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || len(d.secret) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 25<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validSignature(d.secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := r.Header.Get("X-GitHub-Event")
+	switch event {
+	case "ping":
+	case "pull_request":
+		var e pullRequestEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d.applyPullRequest(e, false)
+	case "pull_request_review":
+		var e pullRequestEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if e.Review == nil || !isMe(e.Review.User.Login) || !countReviews {
+			log.Printf("received %s webhook, nothing to update", event)
+			break
+		}
+		d.applyPullRequest(e, true)
+	default:
+		log.Printf("ignoring %s webhook", event)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validSignature(secret []byte, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// applyPullRequest reclassifies the PR from the webhook payload, which has
+// most of what we'd otherwise have gone to the API for, including who merged
+// it.  reviewed says the event was my review of it.
+func (d *daemon) applyPullRequest(e pullRequestEvent, reviewed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p := e.PullRequest.Pull
+	ts, ok := anchorTime(p)
+	if !ok || ts.Year() != d.year {
+		return
+	}
+	p.Timestamp = anchor(p)
+
+	for i := range d.results {
+		result := &d.results[i]
+		if _, name := splitRepo(result.Name); !strings.EqualFold(name, e.Repository.FullName) {
+			continue
+		}
+		p.MyContribution = classify(result.Name, p)
+		if p.MyContribution == "" && reviewed {
+			p.MyContribution = "reviewed"
+		}
+
+		//
+		// The payload doesn't have everything the rules may look at (reviews
+		// and comments are only as fresh as the cache), so hang on to what we
+		// knew already if it can't tell us otherwise.
+		//
+		var pulls []Pull
+		for _, existing := range result.Pulls {
+			if existing.Number != p.Number {
+				pulls = append(pulls, existing)
+			} else if p.MyContribution == "" {
+				p.MyContribution = existing.MyContribution
+			}
+		}
+		if (excludeDrafts && p.Draft) || !countsBase(result.Name, p) || ignored(result.Name, p.Number) || (p.MyContribution != "" && !touchesPaths(result.Name, p)) {
+			p.MyContribution = ""
+		}
+		if p.MyContribution != "" {
+			p.Body = ""
+			pulls = append(pulls, p)
+			annotate(result.Name, pulls[len(pulls)-1:])
+			categorize(result.Name, pulls[len(pulls)-1:])
+		}
+		sort.Sort(PullList(pulls))
+
+		result.Pulls = pulls
+		result.Authored, result.Merged, result.Reviewed = 0, 0, 0
+		for _, pull := range pulls {
+			switch pull.MyContribution {
+			case "authored":
+				result.Authored++
+			case "merged":
+				result.Merged++
+			case "reviewed":
+				result.Reviewed++
+			}
+		}
+		result.Score = score(result.Pulls)
+
+		log.Printf("%s #%d %s: now %q", result.Name, p.Number, e.Action, p.MyContribution)
+		d.save()
+		return
+	}
+}
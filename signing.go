@@ -0,0 +1,79 @@
+package main
+
+//
+// Orgs with supply-chain goals want to know how much of what lands is
+// signed.  With --signing, each repo counts how many of my commits during
+// the period Github verified, and how many of the merge commits of the PRs I
+// merged.  Github checks GPG, SSH and S/MIME signatures; Sigstore (gitsign)
+// signatures it can't check, so those count as signed, but not verified.
+//
+
+var signingStats bool
+
+type Verification struct {
+	Verified bool
+	// Reason is why Github did or didn't verify it; "unsigned" means there
+	// was no signature at all.
+	Reason string
+}
+
+type SigningStats struct {
+	Commits         int
+	SignedCommits   int
+	VerifiedCommits int
+	Merges          int
+	VerifiedMerges  int
+}
+
+func (s SigningStats) CommitRatio() string {
+	return percent(s.VerifiedCommits, s.Commits)
+}
+
+func (s SigningStats) MergeRatio() string {
+	return percent(s.VerifiedMerges, s.Merges)
+}
+
+func (s *SigningStats) countCommit(c Commit) {
+	s.Commits++
+	if c.Commit.Verification.Reason != "unsigned" {
+		s.SignedCommits++
+	}
+	if c.Commit.Verification.Verified {
+		s.VerifiedCommits++
+	}
+}
+
+func loadSigningStats(repo string, year int, pulls []Pull) *SigningStats {
+	var stats SigningStats
+
+	shas := map[string]bool{}
+	for _, author := range myAuthors() {
+		for page := 1; ; page++ {
+			commits := loadCommits(repo, author, year, page)
+			if len(commits) == 0 {
+				break
+			}
+			for _, c := range commits {
+				if !shas[c.Sha] {
+					shas[c.Sha] = true
+					stats.countCommit(c)
+				}
+			}
+		}
+	}
+
+	for _, p := range pulls {
+		if p.MyContribution != "merged" || p.MergeCommitSha == "" {
+			continue
+		}
+		var c Commit
+		if !fetchOptional(repoURL(repo, "/commits/%s", p.MergeCommitSha), &c) {
+			continue
+		}
+		stats.Merges++
+		if c.Commit.Verification.Verified {
+			stats.VerifiedMerges++
+		}
+	}
+	return &stats
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+//
+// Reports that go into formal reviews need to be shown not to have been
+// touched since they were generated.  With --sign-key, every report we write
+// gets a detached signature next to it (report.html.minisig), in the format
+// minisign uses, so either `ghreview verify` or `minisign -V` can check it.
+// The signature's trusted comment says who and what the report is for, and
+// the report's SHA-256, so that's attested too.
+//
+// The key is an Ed25519 private key in PEM, as made by
+//
+//	openssl genpkey -algorithm ed25519 -out ghreview.key
+//
+// rather than a minisign key, since those are encrypted with scrypt, which
+// the standard library doesn't have.  For the same reason, the signatures
+// are minisign's original, non-prehashed kind.  We log the public key in
+// minisign's format when signing, for handing to whoever verifies.
+//
+
+var signKeyPath string
+
+var signingKey ed25519.PrivateKey
+
+// minisign's signature algorithm for signatures over the whole message.
+var minisignAlgorithm = []byte("Ed")
+
+const minisigSuffix string = ".minisig"
+
+func readKey(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM file")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("expected a PRIVATE KEY or PUBLIC KEY, got %s", block.Type)
+}
+
+func checkSignKey(out string) {
+	if signKeyPath == "" {
+		return
+	}
+	if out == "" {
+		log.Fatal("--sign-key needs --out, so that the signature has somewhere to go next to the report")
+	}
+	key, err := readKey(signKeyPath)
+	if err != nil {
+		log.Fatalf("unable to read --sign-key: %s", err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		log.Fatalf("--sign-key must be an Ed25519 private key")
+	}
+	signingKey = private
+	log.Printf("signing reports; verify with minisign -V -P %s", minisignPublicKey(private.Public().(ed25519.PublicKey)))
+}
+
+// keyID is what minisign identifies a key by.  minisign makes up a random
+// one along with the key; ours come from the key itself.
+func keyID(public ed25519.PublicKey) []byte {
+	sum := sha256.Sum256(public)
+	return sum[:8]
+}
+
+func minisignPublicKey(public ed25519.PublicKey) string {
+	raw := append(append(append([]byte{}, minisignAlgorithm...), keyID(public)...), public...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// signReport writes the signature for the report at path.
+func signReport(path string, year int) {
+	if signingKey == nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("unable to read %s back to sign it: %s", path, err)
+	}
+	sum := sha256.Sum256(data)
+	trusted := fmt.Sprintf("ghreview %s report on %s for %s, sha256:%s", currentVersion(), user, periodName(year), hex.EncodeToString(sum[:]))
+
+	public := signingKey.Public().(ed25519.PublicKey)
+	signature := ed25519.Sign(signingKey, data)
+	global := ed25519.Sign(signingKey, append(append([]byte{}, signature...), trusted...))
+	raw := append(append(append([]byte{}, minisignAlgorithm...), keyID(public)...), signature...)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "untrusted comment: signature from ghreview key %s\n", strings.ToUpper(hex.EncodeToString(keyID(public))))
+	fmt.Fprintln(&b, base64.StdEncoding.EncodeToString(raw))
+	fmt.Fprintf(&b, "trusted comment: %s\n", trusted)
+	fmt.Fprintln(&b, base64.StdEncoding.EncodeToString(global))
+	if err := os.WriteFile(path+minisigSuffix, []byte(b.String()), 0644); err != nil {
+		log.Fatalf("unable to write the signature for %s: %s", path, err)
+	}
+}
+
+// verifyReport checks the report at path against its signature, and returns
+// the signature's trusted comment.
+func verifyReport(path string, public ed25519.PublicKey) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sig, err := os.ReadFile(path + minisigSuffix)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", errors.New("the signature file isn't in minisign's format")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("the signature is malformed")
+	}
+	if !bytes.Equal(raw[:2], minisignAlgorithm) {
+		return "", errors.New("the signature is prehashed, which only minisign itself can check")
+	}
+	if !bytes.Equal(raw[2:10], keyID(public)) {
+		return "", errors.New("the report was signed with a different key")
+	}
+	signature := raw[10:]
+	if !ed25519.Verify(public, data, signature) {
+		return "", errors.New("the report doesn't match its signature: it was changed after it was signed")
+	}
+
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(public, append(append([]byte{}, signature...), trusted...), global) {
+		return "", errors.New("the trusted comment doesn't match its signature")
+	}
+	return trusted, nil
+}
+
+func verifyMain(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	keyPath := flags.String("key", "", "the public key (or the private one) to check with, in PEM")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: ghreview verify --key KEY REPORT...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *keyPath == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	key, err := readKey(*keyPath)
+	if err != nil {
+		log.Fatalf("unable to read --key: %s", err)
+	}
+	var public ed25519.PublicKey
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		public = k
+	case ed25519.PrivateKey:
+		public = k.Public().(ed25519.PublicKey)
+	default:
+		log.Fatal("--key must be an Ed25519 key")
+	}
+
+	failed := false
+	for _, path := range flags.Args() {
+		trusted, err := verifyReport(path, public)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: good signature (%s)\n", path, trusted)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//
+// Reports get shared, and a link to one looks a lot better with a preview.
+// The HTML always carries OpenGraph tags with the headline numbers, which
+// is what chat apps and social sites show when a link to it is pasted.
+//
+// With --card, we also draw a 1200x630 card next to the report
+// (report-card.svg for report.html), for attaching to a post or a slide by
+// hand.  It's SVG because the standard library can draw a PNG but can't put
+// text on one, and since the sites that show link previews don't take SVG,
+// the report doesn't point og:image at it.
+//
+
+var socialCard bool
+
+const (
+	cardWidth  int = 1200
+	cardHeight int = 630
+)
+
+// cardPath is the card's file name for a report written to out.
+func cardPath(out string) string {
+	return strings.TrimSuffix(out, filepath.Ext(out)) + "-card.svg"
+}
+
+func checkCard(out string) {
+	if socialCard && out == "" {
+		log.Fatal("--card needs --out, so that the card has somewhere to go next to the report")
+	}
+}
+
+func headline(s Summary) string {
+	text := fmt.Sprintf("Authored %d and merged %d across %d repos.", s.Authored, s.Merged, s.Repos)
+	if s.Reviewed > 0 {
+		text = fmt.Sprintf("Authored %d, merged %d and reviewed %d across %d repos.", s.Authored, s.Merged, s.Reviewed, s.Repos)
+	}
+	return text
+}
+
+func writeMeta(w io.Writer, results []RepoResult, year int) error {
+	s := summarize(results, year, "")
+	title := html.EscapeString(previewTitle(fmt.Sprintf("Contributions by %s in %s", user, periodName(year))))
+	description := html.EscapeString(headline(s))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<title>%s</title>\n", title)
+	fmt.Fprintf(&b, "<meta name=\"description\" content=\"%s\">\n", description)
+	b.WriteString("<meta property=\"og:type\" content=\"website\">\n")
+	fmt.Fprintf(&b, "<meta property=\"og:title\" content=\"%s\">\n", title)
+	fmt.Fprintf(&b, "<meta property=\"og:description\" content=\"%s\">\n", description)
+	b.WriteString("<meta name=\"twitter:card\" content=\"summary\">\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// themeValue is what the report's CSS would use for a custom property, for
+// drawing outside of it.
+func themeValue(name string) string {
+	if value, ok := themeOverrides[name]; ok {
+		return cssValue(value)
+	}
+	return themeDefaults[name]
+}
+
+func writeCard(out string, results []RepoResult, year int) {
+	s := summarize(results, year, "")
+	figures := []struct {
+		label string
+		value int
+	}{
+		{"authored", s.Authored},
+		{"merged", s.Merged},
+		{"reviewed", s.Reviewed},
+		{"repos", s.Repos},
+	}
+	background := themeValue("background")
+	text := themeValue("text")
+	accent := themeValue("accent")
+	muted := themeValue("muted")
+	font := html.EscapeString(themeValue("font"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", cardWidth, cardHeight, cardWidth, cardHeight)
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", html.EscapeString(background))
+	fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"16\" fill=\"%s\"/>\n", html.EscapeString(accent))
+	fmt.Fprintf(&b, "<text x=\"80\" y=\"170\" font-family=\"%s\" font-size=\"64\" font-weight=\"bold\" fill=\"%s\">%s</text>\n", font, html.EscapeString(text), html.EscapeString(user))
+	fmt.Fprintf(&b, "<text x=\"80\" y=\"240\" font-family=\"%s\" font-size=\"40\" fill=\"%s\">Contributions in %s</text>\n", font, html.EscapeString(muted), html.EscapeString(periodName(year)))
+	for i, f := range figures {
+		x := 80 + i*270
+		fmt.Fprintf(&b, "<text x=\"%d\" y=\"440\" font-family=\"%s\" font-size=\"96\" font-weight=\"bold\" fill=\"%s\">%d</text>\n", x, font, html.EscapeString(accent), f.value)
+		fmt.Fprintf(&b, "<text x=\"%d\" y=\"500\" font-family=\"%s\" font-size=\"36\" fill=\"%s\">%s</text>\n", x, font, html.EscapeString(muted), f.label)
+	}
+	b.WriteString("</svg>\n")
+
+	path := cardPath(out)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		log.Fatalf("unable to write %s: %s", path, err)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Stale mode looks forward instead of back: which of my open PRs, and which
+// PRs waiting on my review, haven't moved in a while.  It's the same report,
+// just with different PRs in it.
+//
+
+const staleRequested string = "review-requested"
+
+func staleMain(args []string) {
+	flags := flag.NewFlagSet("stale", flag.ExitOnError)
+	var repos stringList
+	flags.Var(&repos, "repo", "a repo to look in, as owner/repo (may be repeated, or given as arguments)")
+	olderThan := flags.String("older-than", "30d", "how long without updates makes a PR stale, e.g. 90d or 36h")
+	out := flags.String("out", "", "write the report here instead of to stdout (Markdown if it ends in .md, HTML otherwise)")
+	parseFlags(flags, args)
+
+	repos = append(repos, flags.Args()...)
+	if len(repos) == 0 {
+		log.Fatal("usage: ghreview stale [--older-than 90d] --repo owner/repo...")
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		log.Fatalf("bad --older-than: %s", err)
+	}
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+
+	reportHeading = fmt.Sprintf("PRs for %s without updates in %s", user, *olderThan)
+	cutoff := time.Now().Add(-age)
+	var results []RepoResult
+	for _, repo := range repos {
+		results = append(results, collectStale(repo, cutoff))
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+			log.Fatal(err)
+		}
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("unable to create %s: %s", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	year := time.Now().In(location()).Year()
+	if strings.HasSuffix(*out, ".md") {
+		err = renderMarkdown(w, results, year)
+	} else {
+		err = renderHTML(w, results, year)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseAge is time.ParseDuration, plus days.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func collectStale(repo string, cutoff time.Time) RepoResult {
+	result := RepoResult{Name: repo}
+	for page := 1; ; page++ {
+		var pulls []Pull
+		fetch(repoURL(repo, "/pulls?state=open&sort=updated&direction=asc&per_page=100&page=%d", page), &pulls)
+		if len(pulls) == 0 {
+			break
+		}
+
+		//
+		// Oldest updates come first, so we can stop at the first fresh one.
+		//
+		fresh := false
+		for _, p := range pulls {
+			updated, err := parseTimestamp(p.UpdatedAt)
+			if err != nil || !updated.Before(cutoff) {
+				fresh = true
+				break
+			}
+			p.Timestamp = p.UpdatedAt
+			if isMe(p.User.Login) {
+				p.MyContribution = "authored"
+			} else if reviewRequested(p) {
+				p.MyContribution = staleRequested
+			} else {
+				continue
+			}
+			if !ignored(repo, p.Number) {
+				result.add(p)
+			}
+		}
+		if fresh {
+			break
+		}
+	}
+	sort.Sort(PullList(result.Pulls))
+	annotate(repo, result.Pulls)
+	return result
+}
+
+func reviewRequested(p Pull) bool {
+	for _, reviewer := range p.RequestedReviewers {
+		if isMe(reviewer.Login) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// For a lot of maintainers, the year's main output is answering questions.
+// With --support, each repo counts the issues somebody else opened that I
+// commented on during the period, and how many comments that took.  The
+// search API finds the candidates (issues I commented on that were updated
+// during or after the period), and then we count my comments on each.
+//
+
+var supportWork bool
+
+type SupportThread struct {
+	Issue
+	// Comments are how many comments I left on it during the period.
+	Comments int
+}
+
+type SupportWork struct {
+	Comments int
+	Threads  []SupportThread
+}
+
+func loadSupport(repo string, year int) *SupportWork {
+	_, name := splitRepo(repo)
+	first, _ := periodBounds(year)
+	since := first.Format(time.RFC3339)
+
+	var notMine []string
+	for _, login := range myLogins() {
+		notMine = append(notMine, "-author:"+login)
+	}
+
+	work := &SupportWork{}
+	seen := map[int]bool{}
+	for _, login := range myLogins() {
+		query := fmt.Sprintf("repo:%s is:issue commenter:%s %s updated:>=%s", name, login, strings.Join(notMine, " "), since)
+		for _, issue := range searchAll(repo, query) {
+			if seen[issue.Number] || ignored(repo, issue.Number) {
+				continue
+			}
+			seen[issue.Number] = true
+
+			mine := 0
+			for page := 1; page <= commentsMaxPages; page++ {
+				var comments []Comment
+				fetch(repoURL(repo, "/issues/%d/comments?since=%s&per_page=%d&page=%d", issue.Number, since, commentsPageSize, page), &comments)
+				for _, c := range comments {
+					if ts, err := parseTimestamp(c.CreatedAt); err == nil && inPeriod(ts, year) && isMe(c.User.Login) {
+						mine++
+					}
+				}
+				if len(comments) < commentsPageSize {
+					break
+				}
+			}
+			if mine > 0 {
+				work.Threads = append(work.Threads, SupportThread{issue, mine})
+				work.Comments += mine
+			}
+		}
+	}
+	if len(work.Threads) == 0 {
+		return nil
+	}
+	sort.Slice(work.Threads, func(i, j int) bool { return work.Threads[i].Number > work.Threads[j].Number })
+	return work
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//
+// With --template, the report is rendered with your own Go template instead
+// of ours: html/template if the file ends in .html, text/template
+// otherwise.  The template gets a customReport, and on top of the
+// functions our own templates use, these:
+//
+//	humanize "36h"             1 day 12 hours (durations, or seconds as numbers)
+//	plural 3 "PR" "PRs"        3 PRs
+//	percent 3 12               25%
+//	truncate 40 .Title         the title, cut to 40 characters with …
+//	linkify .Name .Title       #12 and owner/repo#34 turned into links
+//	date "de" .Timestamp       2. März 2021 (en, de, fr, es or ja)
+//
+
+var templatePath string
+
+type customReport struct {
+	User   string
+	Year   int
+	Period string
+	// Sample is how many PRs per repo a --sample preview looked at, or 0.
+	Sample int
+	Repos  []RepoResult
+}
+
+var templateFuncs = map[string]any{
+	"timestamp": displayTime,
+	"column":    showColumn,
+	"tickets":   ticketsEnabled,
+	"notes":     notesEnabled,
+	"humanize":  humanize,
+	"plural":    plural,
+	"percent":   percent,
+	"truncate":  truncate,
+	"date":      localDate,
+}
+
+func renderCustom(w io.Writer, results []RepoResult, year int) error {
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		return err
+	}
+	report := customReport{user, year, periodName(year), sampleSize, results}
+	name := filepath.Base(templatePath)
+
+	if ext := strings.ToLower(filepath.Ext(templatePath)); ext == ".html" || ext == ".htm" {
+		funcs := htmltemplate.FuncMap{"linkify": linkifyHTML}
+		for k, v := range templateFuncs {
+			funcs[k] = v
+		}
+		t, err := htmltemplate.New(name).Funcs(funcs).Parse(string(data))
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, report)
+	}
+
+	funcs := template.FuncMap{"linkify": linkifyMarkdown}
+	for k, v := range templateFuncs {
+		funcs[k] = v
+	}
+	t, err := template.New(name).Funcs(funcs).Parse(string(data))
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, report)
+}
+
+func checkTemplate() {
+	if templatePath == "" {
+		return
+	}
+	if _, err := os.Stat(templatePath); err != nil {
+		log.Fatalf("unable to read --template: %s", err)
+	}
+}
+
+// humanize spells out a duration, given as a Go duration string or a
+// number of seconds, to the two largest units.
+func humanize(v any) (string, error) {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	case string:
+		var err error
+		if d, err = parseAge(v); err != nil {
+			return "", err
+		}
+	case int:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	default:
+		return "", fmt.Errorf("humanize: can't make a duration out of %T", v)
+	}
+
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	}
+	var parts []string
+	for _, u := range units {
+		if n := int(d / u.size); n > 0 && len(parts) < 2 {
+			parts = append(parts, plural(n, u.name, u.name+"s"))
+			d -= time.Duration(n) * u.size
+		}
+	}
+	if parts == nil {
+		return "0 seconds", nil
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+func percent(part int, whole int) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", int(math.Round(float64(part)*100/float64(whole))))
+}
+
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+var issueRefPattern = regexp.MustCompile(`(?:\b([\w.-]+/[\w.-]+))?#(\d+)\b`)
+
+// refURL is where a #12 or owner/repo#12 in repo's text points.
+func refURL(repo string, other string, number string) string {
+	host, name := splitRepo(repo)
+	if other != "" {
+		name = other
+	}
+	web := "https://" + host.Name
+	return fmt.Sprintf("%s/%s/issues/%s", web, name, number)
+}
+
+func linkifyMarkdown(repo string, text string) string {
+	return issueRefPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		m := issueRefPattern.FindStringSubmatch(ref)
+		return fmt.Sprintf("[%s](%s)", ref, refURL(repo, m[1], m[2]))
+	})
+}
+
+func linkifyHTML(repo string, text string) htmltemplate.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range issueRefPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(htmltemplate.HTMLEscapeString(text[last:m[0]]))
+		other := ""
+		if m[2] >= 0 {
+			other = text[m[2]:m[3]]
+		}
+		ref := htmltemplate.HTMLEscapeString(text[m[0]:m[1]])
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, htmltemplate.HTMLEscapeString(refURL(repo, other, text[m[4]:m[5]])), ref)
+		last = m[1]
+	}
+	b.WriteString(htmltemplate.HTMLEscapeString(text[last:]))
+	return htmltemplate.HTML(b.String())
+}
+
+var monthNames = map[string][12]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	"fr": {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	"es": {"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+}
+
+// localDate writes the date of a raw timestamp the way the locale does.
+func localDate(locale string, raw string) (string, error) {
+	t, err := parseTimestamp(raw)
+	if err != nil {
+		return "", err
+	}
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	switch lang {
+	case "en":
+		return t.Format("January 2, 2006"), nil
+	case "de":
+		return fmt.Sprintf("%d. %s %d", t.Day(), monthNames[lang][t.Month()-1], t.Year()), nil
+	case "fr", "es":
+		sep := " "
+		if lang == "es" {
+			sep = " de "
+		}
+		return fmt.Sprintf("%d%s%s%s%d", t.Day(), sep, monthNames[lang][t.Month()-1], sep, t.Year()), nil
+	case "ja":
+		return fmt.Sprintf("%d年%d月%d日", t.Year(), t.Month(), t.Day()), nil
+	}
+	return "", fmt.Errorf("date: unknown locale %q, try en, de, fr, es or ja", locale)
+}
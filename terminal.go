@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//
+// Scripts and cron jobs read the report from stdout, so nothing else ever
+// goes there: logging, prompts and progress all go to stderr.  Progress only
+// shows when stderr is a terminal, since in a log file a line redrawn with
+// \r is just noise, and it's only in color when NO_COLOR isn't set (see
+// no-color.org) and the terminal isn't a dumb one.
+//
+
+// isTerminal says whether f is a terminal, rather than a pipe or a file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func colorEnabled() bool {
+	return os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && isTerminal(os.Stderr)
+}
+
+// bold is s in bold, if we're doing color.
+func bold(s string) string {
+	if !colorEnabled() {
+		return s
+	}
+	return "\033[1m" + s + "\033[0m"
+}
+
+// stderr is where the log goes.  It wipes the progress line before
+// anything else is written, so that the two don't end up on one line.
+type stderr struct {
+	mu      sync.Mutex
+	w       io.Writer
+	showing bool
+}
+
+var statusLine = &stderr{w: os.Stderr}
+
+func (s *stderr) clear() {
+	if s.showing {
+		io.WriteString(s.w, "\r\033[K")
+		s.showing = false
+	}
+}
+
+func (s *stderr) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear()
+	return s.w.Write(p)
+}
+
+// progress shows how far along collecting is, on a line of its own that the
+// next update replaces.
+func (s *stderr) progress(done int, total int, result RepoResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear()
+	fmt.Fprintf(s.w, "%s %s", bold(fmt.Sprintf("[%d/%d]", done, total)), result.Name)
+	s.showing = true
+}
+
+func (s *stderr) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//
+// The report's CSS takes its colors and fonts from custom properties, so
+// that a --theme-file can rebrand it without replacing any of it.  The file
+// is just declarations of the properties below, and of the palette's (see
+// palette.go), optionally inside :root { }:
+//
+//	--font: "Inter", sans-serif;
+//	--accent: #c8102e;
+//	--state-open: #c8102e;
+//
+// Anything else in there is an error, so that a typo doesn't silently do
+// nothing.
+//
+
+var themeFile string
+
+var themeDefaults = map[string]string{
+	"font":            "sans-serif",
+	"text":            "black",
+	"background":      "white",
+	"link":            "LinkText",
+	"accent":          "black",
+	"muted":           "hsl(0, 0%, 40%)",
+	"border":          "black",
+	"row-odd":         "hsl(0, 100%, 100%)",
+	"row-even":        "hsl(0, 0%, 90%)",
+	"code-background": "hsl(0, 0%, 94%)",
+	"label-border":    "hsl(0, 0%, 60%)",
+	"away":            "hsl(0, 0%, 88%)",
+	"away-alternate":  "hsl(0, 0%, 96%)",
+}
+
+var themeOverrides map[string]string
+
+var (
+	cssComment     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssDeclaration = regexp.MustCompile(`^--([a-z0-9-]+)\s*:\s*(.+)$`)
+)
+
+func checkTheme() {
+	if themeFile == "" {
+		return
+	}
+	data, err := os.ReadFile(themeFile)
+	if err != nil {
+		log.Fatalf("unable to read --theme-file: %s", err)
+	}
+	text := strings.TrimSpace(cssComment.ReplaceAllString(string(data), ""))
+	if inner, ok := strings.CutPrefix(text, ":root"); ok {
+		inner = strings.TrimSpace(inner)
+		if !strings.HasPrefix(inner, "{") || !strings.HasSuffix(inner, "}") {
+			log.Fatalf("%s: expected :root { ... }", themeFile)
+		}
+		text = inner[1 : len(inner)-1]
+	}
+
+	themeOverrides = map[string]string{}
+	for _, decl := range strings.Split(text, ";") {
+		decl = strings.TrimSpace(decl)
+		if decl == "" {
+			continue
+		}
+		m := cssDeclaration.FindStringSubmatch(decl)
+		if m == nil {
+			log.Fatalf("%s: expected only custom properties like --accent: #c00, got %q", themeFile, decl)
+		}
+		_, known := themeDefaults[m[1]]
+		_, inPalette := palettes["default"][m[1]]
+		if !known && !inPalette {
+			log.Fatalf("%s: unknown property --%s", themeFile, m[1])
+		}
+		themeOverrides[m[1]] = strings.TrimSpace(m[2])
+	}
+}
+
+// cssValue keeps a value from breaking out of its declaration, or out of
+// the style element.
+func cssValue(s string) string {
+	return strings.NewReplacer(";", "", "{", "", "}", "", "<", "").Replace(s)
+}
+
+func writeTheme(w io.Writer) error {
+	values := map[string]string{}
+	for name, value := range themeDefaults {
+		values[name] = value
+	}
+	for name, value := range themeOverrides {
+		values[name] = value
+	}
+
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("<style>\n:root {\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "    --%s: %s;\n", name, cssValue(values[name]))
+	}
+	b.WriteString("}\n</style>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
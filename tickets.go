@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"sort"
+)
+
+//
+// Besides Jira (see jira.go), PRs often mention tickets in other trackers.
+// Those we recognize with the patterns from the config, and link to using
+// their URL templates.  Nothing is looked up, so all we can group by is the
+// ticket itself.
+//
+
+type Ticket struct {
+	Tracker string
+	Key     string
+	URL     string
+}
+
+type TicketPull struct {
+	Repo string
+	Pull
+}
+
+type TicketGroup struct {
+	Key   string
+	URL   string
+	Pulls []TicketPull
+}
+
+type tracker struct {
+	TrackerConfig
+	re *regexp.Regexp
+}
+
+var trackers []tracker
+
+func ticketsEnabled() bool {
+	return jiraEnabled() || len(config.Trackers) > 0
+}
+
+func compileTrackers() {
+	if trackers != nil {
+		return
+	}
+	trackers = []tracker{}
+	for _, tc := range config.Trackers {
+		re, err := regexp.Compile(`\b` + tc.Pattern + `\b`)
+		if err != nil {
+			log.Fatalf("bad pattern for tracker %s: %s", tc.Name, err)
+		}
+		trackers = append(trackers, tracker{tc, re})
+	}
+}
+
+func trackerTickets(texts ...string) []Ticket {
+	compileTrackers()
+	var tickets []Ticket
+	for _, text := range texts {
+		for _, t := range trackers {
+			for _, m := range t.re.FindAllStringSubmatchIndex(text, -1) {
+				url := t.re.ExpandString(nil, t.URL, text, m)
+				tickets = append(tickets, Ticket{t.Name, text[m[0]:m[1]], string(url)})
+			}
+		}
+	}
+	return tickets
+}
+
+// linkTickets finds the tickets that each PR mentions in its title or in the
+// name of its branch.
+func linkTickets(pulls []Pull) {
+	for i := range pulls {
+		texts := []string{pulls[i].Title, pulls[i].Head.Ref}
+		var found []Ticket
+		if jiraEnabled() {
+			found = jiraTickets(texts...)
+		}
+		found = append(found, trackerTickets(texts...)...)
+
+		pulls[i].Tickets = nil
+		seen := map[string]bool{}
+		for _, t := range found {
+			if !seen[t.URL] {
+				seen[t.URL] = true
+				pulls[i].Tickets = append(pulls[i].Tickets, t)
+			}
+		}
+	}
+}
+
+func groupTickets(results []RepoResult) []TicketGroup {
+	grouped := map[string]bool{}
+	for _, tc := range config.Trackers {
+		if tc.Group {
+			grouped[tc.Name] = true
+		}
+	}
+	if len(grouped) == 0 {
+		return nil
+	}
+
+	byURL := map[string]*TicketGroup{}
+	for _, result := range results {
+		for _, p := range result.Pulls {
+			for _, t := range p.Tickets {
+				if !grouped[t.Tracker] {
+					continue
+				}
+				if byURL[t.URL] == nil {
+					byURL[t.URL] = &TicketGroup{Key: t.Key, URL: t.URL}
+				}
+				byURL[t.URL].Pulls = append(byURL[t.URL].Pulls, TicketPull{result.Name, p})
+			}
+		}
+	}
+
+	var groups []TicketGroup
+	for _, g := range byURL {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
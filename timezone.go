@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+//
+// The API gives us UTC, but a PR opened on New Year's Eve in Tokyo belongs
+// to the new year as far as its author is concerned.  So we keep the raw
+// timestamps around and only convert them to the configured zone when we
+// need to know the date, or show it.
+//
+
+const defaultTimeFormat string = "2006-01-02"
+
+var (
+	zone     *time.Location
+	zoneOnce sync.Once
+)
+
+func location() *time.Location {
+	zoneOnce.Do(func() {
+		zone = time.UTC
+		if config.TimeZone != "" {
+			loc, err := time.LoadLocation(config.TimeZone)
+			if err != nil {
+				log.Fatalf("unknown time zone %q: %s", config.TimeZone, err)
+			}
+			zone = loc
+		}
+	})
+	return zone
+}
+
+// parseTimestamp understands full timestamps, and plain dates, which is
+// what older serve state files have.
+func parseTimestamp(raw string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		t, err = time.Parse(defaultTimeFormat, raw)
+		if err != nil {
+			return t, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location()), nil
+	}
+	return t.In(location()), nil
+}
+
+func displayTime(raw string) string {
+	t, err := parseTimestamp(raw)
+	if err != nil {
+		return raw
+	}
+	format := config.TimeFormat
+	if format == "" {
+		format = defaultTimeFormat
+	}
+	return t.Format(format)
+}
+
+//
+// Which timestamp anchors a PR to the year is up to the user: a PR opened in
+// December and merged in January counts for the first year by default, and
+// for the second with --match-on merged.
+//
+
+const (
+	matchCreated string = "created"
+	matchMerged  string = "merged"
+	matchClosed  string = "closed"
+)
+
+var matchOn string = matchCreated
+
+// anchor is the raw timestamp that decides which year the PR counts for.
+// It's empty if the PR hasn't got that far yet, e.g. if it isn't merged.
+func anchor(pull Pull) string {
+	switch matchOn {
+	case matchMerged:
+		return pull.MergedAt
+	case matchClosed:
+		return pull.ClosedAt
+	}
+	return pull.CreatedAt
+}
+
+func anchorTime(pull Pull) (time.Time, bool) {
+	raw := anchor(pull)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	t, err := parseTimestamp(raw)
+	if err != nil {
+		log.Fatalf("unable to parse time from %s", raw)
+	}
+	return t, true
+}
+
+// Merging or closing a PR updates it, so when we go by those we can still
+// stop paging early, as long as we have the list sorted by update time.
+func listSort() string {
+	if matchOn == matchCreated {
+		return "created"
+	}
+	return "updated"
+}
+
+func sortTime(pull Pull) time.Time {
+	if matchOn == matchCreated || pull.UpdatedAt == "" {
+		return parseTime(pull)
+	}
+	t, err := parseTimestamp(pull.UpdatedAt)
+	if err != nil {
+		log.Fatalf("unable to parse time from %s", pull.UpdatedAt)
+	}
+	return t
+}
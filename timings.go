@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+//
+// With --timings, we keep track of how long each repo spends in each phase,
+// and print a table at the end of the run, so that it's obvious which repos
+// dominate and whether caching them harder would help.
+//
+
+const (
+	phaseList    string = "list PRs"
+	phaseEvents  string = "fetch events"
+	phaseReviews string = "fetch reviews"
+	phaseGraphQL string = "graphql"
+	phaseCommits string = "map commits"
+	phaseGit     string = "git stats"
+	phaseRender  string = "render"
+)
+
+var phases = []string{phaseList, phaseGraphQL, phaseEvents, phaseReviews, phaseCommits, phaseGit, phaseRender}
+
+// Rendering isn't about any particular repo, so it goes under this row.
+const allRepos string = "(all)"
+
+var showTimings bool
+
+var timings = map[string]map[string]time.Duration{}
+
+var timedRepos []string
+
+// timed adds the time since start to repo's phase.
+func timed(repo, phase string, start time.Time) {
+	if !showTimings {
+		return
+	}
+	if timings[repo] == nil {
+		timings[repo] = map[string]time.Duration{}
+		timedRepos = append(timedRepos, repo)
+	}
+	timings[repo][phase] += time.Since(start)
+}
+
+func printTimings() {
+	if !showTimings || len(timedRepos) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "repo\t")
+	for _, phase := range phases {
+		fmt.Fprintf(w, "%s\t", phase)
+	}
+	fmt.Fprintln(w, "total\t")
+
+	for _, repo := range timedRepos {
+		fmt.Fprintf(w, "%s\t", repo)
+		var total time.Duration
+		for _, phase := range phases {
+			d := timings[repo][phase]
+			total += d
+			fmt.Fprintf(w, "%s\t", d.Round(time.Millisecond))
+		}
+		fmt.Fprintf(w, "%s\t\n", total.Round(time.Millisecond))
+	}
+	w.Flush()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//
+// Org-wide runs take long enough that it's worth seeing where the time goes.
+// With --otlp, we record spans for each phase (collecting a repo, rendering)
+// and for each cache access and wire fetch inside it, and ship them to an
+// OpenTelemetry collector at the end of the run.  Like the Redis client, this
+// is just enough OTLP (the JSON flavor over HTTP) to avoid a dependency.
+//
+// Fetches and cache accesses hang off whichever phase is current, which is
+// good enough because phases don't run concurrently.
+//
+
+const otlpEndpointEnv string = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// We export in batches, so that a long-running serve doesn't hoard spans.
+const maxPendingSpans int = 1000
+
+type span struct {
+	traceID string
+	id      string
+	parent  string
+	name    string
+	start   time.Time
+	end     time.Time
+	attrs   map[string]string
+	phase   bool
+	prev    *span
+}
+
+var otlpEndpoint string
+
+var tracer struct {
+	mu      sync.Mutex
+	root    *span
+	current *span
+	pending []*span
+}
+
+func randomID(n int) string {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func startTracing(name string) {
+	if otlpEndpoint == "" {
+		return
+	}
+	root := &span{traceID: randomID(16), id: randomID(8), name: name, start: time.Now()}
+	tracer.root = root
+	tracer.current = root
+}
+
+func newSpan(name string, attrs ...string) *span {
+	if tracer.root == nil {
+		return nil
+	}
+	s := &span{name: name, start: time.Now(), attrs: map[string]string{}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+	s.id = randomID(8)
+	s.traceID = tracer.root.traceID
+	return s
+}
+
+// startSpan starts a span for a single operation within the current phase.
+func startSpan(name string, attrs ...string) *span {
+	s := newSpan(name, attrs...)
+	if s == nil {
+		return nil
+	}
+	tracer.mu.Lock()
+	s.parent = tracer.current.id
+	tracer.mu.Unlock()
+	return s
+}
+
+// startPhase starts a span that everything else started before it ends
+// belongs to.
+func startPhase(name string, attrs ...string) *span {
+	s := newSpan(name, attrs...)
+	if s == nil {
+		return nil
+	}
+	tracer.mu.Lock()
+	s.parent = tracer.current.id
+	s.phase = true
+	s.prev = tracer.current
+	tracer.current = s
+	tracer.mu.Unlock()
+	return s
+}
+
+func (s *span) set(key, value string) {
+	if s != nil {
+		s.attrs[key] = value
+	}
+}
+
+func (s *span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+
+	tracer.mu.Lock()
+	if s.phase && tracer.current == s {
+		tracer.current = s.prev
+	}
+	tracer.pending = append(tracer.pending, s)
+	var batch []*span
+	if len(tracer.pending) >= maxPendingSpans {
+		batch = tracer.pending
+		tracer.pending = nil
+	}
+	tracer.mu.Unlock()
+
+	if batch != nil {
+		exportSpans(batch)
+	}
+}
+
+// finishTracing ends the root span and sends off whatever hasn't been sent.
+func finishTracing() {
+	if tracer.root == nil {
+		return
+	}
+	tracer.root.End()
+	tracer.mu.Lock()
+	batch := tracer.pending
+	tracer.pending = nil
+	tracer.mu.Unlock()
+	exportSpans(batch)
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+}
+
+func exportSpans(spans []*span) {
+	if len(spans) == 0 {
+		return
+	}
+
+	var out []otlpSpan
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           s.traceID,
+			SpanID:            s.id,
+			ParentSpanID:      s.parent,
+			Name:              s.name,
+			Kind:              1, // internal
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		for k, v := range s.attrs {
+			o.Attributes = append(o.Attributes, otlpAttribute{k, otlpValue{v}})
+		}
+		out = append(out, o)
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{{"service.name", otlpValue{"ghreview"}}},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": "ghreview"},
+				"spans": out,
+			}},
+		}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//
+	// This deliberately doesn't go through our client: there's nothing to
+	// cache, and the collector isn't a Github host we need to pace.
+	//
+	url := strings.TrimSuffix(otlpEndpoint, "/") + "/v1/traces"
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Printf("unable to export %d spans to %s: %s", len(spans), url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode > 299 {
+		log.Printf("unable to export %d spans to %s: HTTP %d", len(spans), url, resp.StatusCode)
+	}
+}
+
+func defaultOTLPEndpoint() string {
+	return os.Getenv(otlpEndpointEnv)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//
+// The interactive mode shows how collection is going, one repo at a time,
+// and then lets you browse and narrow down the contributions before
+// exporting exactly the view you want.  It's line-based, with a prompt,
+// rather than a full-screen UI, because that needs nothing beyond the
+// standard library and works in any terminal (and over a pipe).
+//
+
+const tuiPageSize int = 20
+
+const tuiHelp string = `commands:
+  n, <enter>    next page
+  p             previous page
+  / text        only show PRs whose repo or title contains text (/ alone clears)
+  k kind        only show authored, merged, reviewed or committed PRs (k alone clears)
+  h file        export the current view as HTML
+  m file        export the current view as Markdown
+  ?             this help
+  q             quit`
+
+type tuiRow struct {
+	repo string
+	pull Pull
+}
+
+type tuiView struct {
+	results []RepoResult
+	year    int
+	filter  string
+	kind    string
+	page    int
+	// shown is whether a page is on the screen already, and not just what
+	// collection logged.
+	shown bool
+}
+
+func tuiMain(args []string) {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	opts := addReportFlags(flags)
+	parseFlags(flags, args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("usage: ghreview tui owner/repo...")
+	}
+	opts.onRepo = func(done int, total int, result RepoResult) {
+		fmt.Printf("[%d/%d] %s: %d authored, %d merged, %d reviewed, %d committed\n",
+			done, total, result.Name, result.Authored, result.Merged, result.Reviewed, result.Committed)
+	}
+	v := &tuiView{results: opts.collect(flags.Args()), year: *opts.year}
+
+	in := bufio.NewScanner(os.Stdin)
+	v.show()
+	for {
+		fmt.Print("> ")
+		if !in.Scan() {
+			return
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "", "n":
+			v.page++
+		case "p":
+			if v.page > 0 {
+				v.page--
+			}
+		case "/":
+			v.filter, v.page = strings.ToLower(arg), 0
+		case "k":
+			v.kind, v.page = arg, 0
+		case "h", "m":
+			v.export(command, arg)
+			continue
+		case "?":
+			fmt.Println(tuiHelp)
+			continue
+		case "q":
+			return
+		default:
+			fmt.Printf("unknown command %q, ? for help\n", command)
+			continue
+		}
+		v.show()
+	}
+}
+
+func (v *tuiView) matches(repo string, p Pull) bool {
+	if v.kind != "" && p.MyContribution != v.kind {
+		return false
+	}
+	if v.filter != "" && !strings.Contains(strings.ToLower(repo+" "+p.Title), v.filter) {
+		return false
+	}
+	return true
+}
+
+func (v *tuiView) rows() []tuiRow {
+	var rows []tuiRow
+	for _, result := range v.results {
+		for _, p := range result.Pulls {
+			if v.matches(result.Name, p) {
+				rows = append(rows, tuiRow{result.Name, p})
+			}
+		}
+	}
+	return rows
+}
+
+func (v *tuiView) show() {
+	rows := v.rows()
+	pages := (len(rows) + tuiPageSize - 1) / tuiPageSize
+	if v.page >= pages && pages > 0 {
+		v.page = pages - 1
+	}
+
+	//
+	// Redraw each page over the last, but leave what collection said on the
+	// screen above the first one.
+	//
+	if isTerminal(os.Stdout) && v.shown {
+		fmt.Print("\033[H\033[2J")
+	}
+	v.shown = true
+	fmt.Printf("%d PRs", len(rows))
+	if v.filter != "" {
+		fmt.Printf(" matching %q", v.filter)
+	}
+	if v.kind != "" {
+		fmt.Printf(", %s only", v.kind)
+	}
+	fmt.Printf(", page %d of %d (? for help)\n\n", v.page+1, max(pages, 1))
+
+	start := v.page * tuiPageSize
+	for i := start; i < len(rows) && i < start+tuiPageSize; i++ {
+		r := rows[i]
+		title := truncate(60, r.pull.Title)
+		fmt.Printf("%-10s  %-24s %6s  %-9s %-6s  %s\n", displayTime(r.pull.Timestamp), r.repo, "#"+strconv.Itoa(r.pull.Number), r.pull.MyContribution, r.pull.DisplayState(), title)
+	}
+}
+
+// view is the results with only the PRs we're showing, counted afresh.
+func (v *tuiView) view() []RepoResult {
+	var results []RepoResult
+	for _, result := range v.results {
+		narrowed := result
+		narrowed.Pulls = nil
+		narrowed.Authored, narrowed.Merged, narrowed.Reviewed, narrowed.Committed = 0, 0, 0, 0
+		for _, p := range result.Pulls {
+			if !v.matches(result.Name, p) {
+				continue
+			}
+			narrowed.add(p)
+		}
+		narrowed.Score = score(narrowed.Pulls)
+		results = append(results, narrowed)
+	}
+	return results
+}
+
+func (v *tuiView) export(format string, path string) {
+	if path == "" {
+		fmt.Println("export to which file?")
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("unable to create %s: %s\n", path, err)
+		return
+	}
+	defer f.Close()
+
+	if format == "m" {
+		err = renderMarkdown(f, v.view(), v.year)
+	} else {
+		err = renderHTML(f, v.view(), v.year)
+	}
+	if err != nil {
+		fmt.Printf("unable to export to %s: %s\n", path, err)
+		return
+	}
+	fmt.Printf("wrote %s\n", path)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+//
+// How well the cache is doing, and how much of the API budget a run used,
+// is what you need to know to pick a sensible --ttl.  We count as we go and
+// log a summary at the end of the run.
+//
+
+type Usage struct {
+	// CacheHits are responses served from the cache without asking the
+	// server; NotModified are the ones the server had to confirm first.
+	CacheHits    int64
+	Network      int64
+	NotModified  int64
+	BytesFetched int64
+	// RateLimit is how many requests each host had left, as of the last
+	// response from it.
+	RateLimit map[string]int `json:",omitempty"`
+}
+
+var usage struct {
+	cacheHits   atomic.Int64
+	network     atomic.Int64
+	notModified atomic.Int64
+	bytes       atomic.Int64
+}
+
+// countingReader tallies the bytes of a response body as they're read off
+// the wire.
+type countingReader struct {
+	io.ReadCloser
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	usage.bytes.Add(int64(n))
+	return n, err
+}
+
+func currentUsage() Usage {
+	u := Usage{
+		CacheHits:    usage.cacheHits.Load(),
+		Network:      usage.network.Load(),
+		NotModified:  usage.notModified.Load(),
+		BytesFetched: usage.bytes.Load(),
+	}
+
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	for name, h := range hosts {
+		h.mu.Lock()
+		if h.remaining >= 0 {
+			if u.RateLimit == nil {
+				u.RateLimit = map[string]int{}
+			}
+			u.RateLimit[name] = h.remaining
+		}
+		h.mu.Unlock()
+	}
+	return u
+}
+
+func (u Usage) String() string {
+	total := u.CacheHits + u.Network
+	var rate float64
+	if total > 0 {
+		rate = 100 * float64(u.CacheHits) / float64(total)
+	}
+	text := fmt.Sprintf("%d requests: %d from the cache (%.0f%%), %d over the network (%d unchanged), %s fetched",
+		total, u.CacheHits, rate, u.Network, u.NotModified, formatBytes(u.BytesFetched))
+
+	var names []string
+	for name := range u.RateLimit {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var limits []string
+	for _, name := range names {
+		limits = append(limits, fmt.Sprintf("%s has %d left", name, u.RateLimit[name]))
+	}
+	if len(limits) > 0 {
+		text += "; " + strings.Join(limits, ", ")
+	}
+	return text
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+func printUsage() {
+	u := currentUsage()
+	if u.CacheHits+u.Network > 0 {
+		log.Print(u)
+	}
+}
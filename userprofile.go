@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//
+// Reports get shared, and a face and a name at the top make them look less
+// like a dump of API output.  With --profile, we fetch the user's profile
+// from the host of the first repo and put it in a header.  If the profile
+// isn't there (a typo in --user, or an identity without a login on that
+// host), we just leave the header out.  The commit authors from --git-dir get
+// their avatars too, from whichever account the host ties their email to.
+//
+
+var showProfile bool
+
+type Profile struct {
+	Login     string
+	Name      string
+	Bio       string
+	Company   string
+	Location  string
+	HtmlUrl   string `json:"html_url"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+// DisplayName is what we call the user in the header.
+func (p Profile) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Login
+}
+
+func loadProfile(results []RepoResult) *Profile {
+	if !showProfile {
+		return nil
+	}
+	host := hostFor(defaultHost)
+	if len(results) > 0 {
+		host, _ = splitRepo(results[0].Name)
+	}
+	var profile Profile
+	if !fetchOptional(fmt.Sprintf("%s/users/%s", host.API, url.PathEscape(myLogins()[0])), &profile) {
+		return nil
+	}
+	return &profile
+}
+
+// loadAvatars finds the avatars of the authors in stats.  The commits API
+// knows which account an email belongs to, so we ask it for one commit each.
+func loadAvatars(repo string, stats []AuthorStats) {
+	if !showProfile {
+		return
+	}
+	for i := range stats {
+		if stats[i].email == "" {
+			continue
+		}
+		var commits []struct {
+			Author *struct {
+				AvatarUrl string `json:"avatar_url"`
+			}
+		}
+		fetch(repoURL(repo, "/commits?author=%s&per_page=1", url.QueryEscape(stats[i].email)), &commits)
+		if len(commits) > 0 && commits[0].Author != nil {
+			stats[i].AvatarUrl = commits[0].Author.AvatarUrl
+		}
+	}
+}
+
+const profileTempl string = `<header class="profile">
+    {{ if .AvatarUrl }}<img src="{{ .AvatarUrl }}" alt="" width="96" height="96">{{ end }}
+    <div>
+        <p class="name"><a href="{{ .HtmlUrl }}">{{ .DisplayName }}</a>{{ if .Name }} <span class="login">@{{ .Login }}</span>{{ end }}</p>
+        {{ with .Bio }}<p>{{ . }}</p>{{ end }}
+        {{ if or .Company .Location }}<p class="where">{{ .Company }}{{ if and .Company .Location }} · {{ end }}{{ .Location }}</p>{{ end }}
+    </div>
+</header>
+`
+
+var profileHeader = template.Must(template.New("profile").Parse(profileTempl))
+
+func writeProfile(w io.Writer, profile *Profile) error {
+	if profile == nil {
+		return nil
+	}
+	return profileHeader.Execute(w, profile)
+}
+
+func writeProfileMarkdown(w io.Writer, profile *Profile) {
+	if profile == nil {
+		return
+	}
+	fmt.Fprintln(w)
+	if profile.AvatarUrl != "" {
+		fmt.Fprintf(w, "<img src=%q alt=\"\" width=\"96\" height=\"96\" align=\"left\">\n\n", profile.AvatarUrl)
+	}
+	fmt.Fprintf(w, "**[%s](%s)**", markdownText(profile.DisplayName()), profile.HtmlUrl)
+	if profile.Name != "" {
+		fmt.Fprintf(w, " @%s", profile.Login)
+	}
+	fmt.Fprintln(w)
+	if profile.Bio != "" {
+		fmt.Fprintf(w, "\n%s\n", markdownText(profile.Bio))
+	}
+	if where := strings.Trim(profile.Company+" · "+profile.Location, " ·"); where != "" {
+		fmt.Fprintf(w, "\n%s\n", markdownText(where))
+	}
+	fmt.Fprintln(w, `<br clear="left">`)
+}
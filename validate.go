@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//
+// A typo in the seventh repo used to surface as an HTTP 404 after the first
+// six had been fetched.  So before collecting anything, we look up every
+// repo, and if any of them doesn't exist, can't be seen with our token, or
+// has nothing in it, we say so for all of them at once and stop.  The
+// lookups are cached like everything else, so this costs nothing the second
+// time around.
+//
+
+type repoMetadata struct {
+	Size     int
+	PushedAt string `json:"pushed_at"`
+}
+
+// repoProblem says what's wrong with repo, if anything.
+func repoProblem(repo string) string {
+	host, _ := splitRepo(repo)
+	resp, err := client.Get(repoURL(repo, ""))
+	if err != nil {
+		return err.Error()
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err.Error()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Sprintf("the token for %s was rejected", host.Name)
+	case resp.StatusCode == http.StatusNotFound && host.Token == "":
+		return fmt.Sprintf("not found; if it's private, set a token for %s", host.Name)
+	case resp.StatusCode == http.StatusNotFound:
+		return "not found, or the token can't see it"
+	case resp.StatusCode == http.StatusForbidden:
+		return "the token isn't allowed to read it"
+	case resp.StatusCode > 299:
+		return fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+
+	var meta repoMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return fmt.Sprintf("unable to make sense of its metadata: %s", err)
+	}
+	if meta.Size == 0 && meta.PushedAt == "" {
+		return "it's empty"
+	}
+	return ""
+}
+
+func validateRepos(repos []string) {
+	var problems []string
+	for _, repo := range repos {
+		if problem := repoProblem(repo); problem != "" {
+			problems = append(problems, fmt.Sprintf("  %s: %s", repo, problem))
+		}
+	}
+	if len(problems) > 0 {
+		log.Fatalf("unable to report on %d of %d repos:\n%s", len(problems), len(repos), strings.Join(problems, "\n"))
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+//
+// Most people run this once a year, and by then whatever they have is
+// stale, so it can tell you what it is and replace itself with the latest
+// release.  Releases are built by goreleaser, which sets version via
+//
+//	-ldflags "-X main.version=v1.2.3"
+//
+// and publishes an archive per platform alongside a checksums.txt, which we
+// check the download against before installing it.  We only ever move to a
+// newer release: a build that isn't a release (a dev build, or a go install
+// of a commit) only gets replaced with --force.
+//
+
+const releaseRepo string = "mpenkov/ghreview"
+
+var version = "dev"
+
+func versionMain(args []string) {
+	flags := flag.NewFlagSet("version", flag.ExitOnError)
+	flags.Parse(args)
+
+	fmt.Printf("ghreview %s\n", currentVersion())
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Printf("built with %s for %s/%s\n", info.GoVersion, runtime.GOOS, runtime.GOARCH)
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				fmt.Printf("%s: %s\n", s.Key, s.Value)
+			}
+		}
+	}
+}
+
+// currentVersion prefers what the release build stamped in, and falls back
+// to what go install recorded.
+func currentVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+var releaseVersion = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?$`)
+
+// pseudoVersion is the prerelease part of the version go install gives a
+// commit, e.g. v0.0.0-20240101120000-abcdef123456.
+var pseudoVersion = regexp.MustCompile(`(^|\.)\d{14}-[0-9a-f]{12}$`)
+
+type semver struct {
+	numbers    [3]int
+	prerelease string
+}
+
+// parseRelease parses v, if it's the version of a release.
+func parseRelease(v string) (semver, bool) {
+	m := releaseVersion.FindStringSubmatch(v)
+	if m == nil || pseudoVersion.MatchString(m[4]) {
+		return semver{}, false
+	}
+	var sv semver
+	for i := range sv.numbers {
+		sv.numbers[i], _ = strconv.Atoi(m[i+1])
+	}
+	sv.prerelease = m[4]
+	return sv, true
+}
+
+// newer says whether a is a later release than b.  Prereleases come before
+// the release they lead up to, and otherwise compare as strings, which is
+// close enough for rc1 and rc2.
+func (a semver) newer(b semver) bool {
+	for i := range a.numbers {
+		if a.numbers[i] != b.numbers[i] {
+			return a.numbers[i] > b.numbers[i]
+		}
+	}
+	switch {
+	case a.prerelease == b.prerelease:
+		return false
+	case a.prerelease == "":
+		return true
+	case b.prerelease == "":
+		return false
+	}
+	return a.prerelease > b.prerelease
+}
+
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string
+		URL  string `json:"browser_download_url"`
+	}
+}
+
+func selfUpdateMain(args []string) {
+	flags := flag.NewFlagSet("self-update", flag.ExitOnError)
+	check := flags.Bool("check", false, "only say whether there's a newer release")
+	api := flags.String("api", "https://api.github.com", "where to look for releases")
+	force := flags.Bool("force", false, "replace a build that isn't a release with the latest release")
+	flags.Parse(args)
+
+	var latest release
+	if err := getJSON(fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(*api, "/"), releaseRepo), &latest); err != nil {
+		log.Fatalf("unable to find the latest release: %s", err)
+	}
+	current := currentVersion()
+	latestVersion, ok := parseRelease(latest.TagName)
+	if !ok {
+		log.Fatalf("the latest release is %q, which isn't a version", latest.TagName)
+	}
+	if running, ok := parseRelease(current); !ok {
+		if *check {
+			fmt.Printf("ghreview %s is out, this is %s, which isn't a release\n", latest.TagName, current)
+			return
+		}
+		if !*force {
+			log.Fatalf("this is %s, which isn't a release, so it may well be newer than %s; use --force to replace it anyway", current, latest.TagName)
+		}
+	} else if !latestVersion.newer(running) {
+		fmt.Printf("ghreview %s is up to date, the latest release is %s\n", current, latest.TagName)
+		return
+	}
+	if *check {
+		fmt.Printf("ghreview %s is out, this is %s\n", latest.TagName, current)
+		return
+	}
+
+	var archiveURL, archiveName, sumsURL string
+	for _, a := range latest.Assets {
+		name := strings.ToLower(a.Name)
+		switch {
+		case strings.HasSuffix(name, "checksums.txt"):
+			sumsURL = a.URL
+		case forThisPlatform(name):
+			archiveURL, archiveName = a.URL, a.Name
+		}
+	}
+	if archiveURL == "" || sumsURL == "" {
+		log.Fatalf("release %s has nothing for %s/%s", latest.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+
+	archive, err := download(archiveURL)
+	if err != nil {
+		log.Fatalf("unable to download %s: %s", archiveName, err)
+	}
+	sums, err := download(sumsURL)
+	if err != nil {
+		log.Fatalf("unable to download the checksums: %s", err)
+	}
+	if want, got := expectedSum(sums, archiveName), sha256.Sum256(archive); want == "" || want != hex.EncodeToString(got[:]) {
+		log.Fatalf("%s doesn't match its checksum, not installing it", archiveName)
+	}
+
+	binary, err := extractBinary(archiveName, archive)
+	if err != nil {
+		log.Fatalf("unable to extract ghreview from %s: %s", archiveName, err)
+	}
+	if err := replaceExecutable(binary); err != nil {
+		log.Fatalf("unable to install %s: %s", latest.TagName, err)
+	}
+	fmt.Printf("updated ghreview from %s to %s\n", current, latest.TagName)
+}
+
+func getJSON(url string, v any) error {
+	data, err := download(url)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// forThisPlatform recognizes goreleaser's archive names, old and new, e.g.
+// ghreview_1.2.3_linux_amd64.tar.gz and ghreview_1.2.3_Darwin_x86_64.tar.gz.
+func forThisPlatform(name string) bool {
+	if !strings.HasSuffix(name, ".tar.gz") && !strings.HasSuffix(name, ".zip") {
+		return false
+	}
+	arches := map[string][]string{"amd64": {"amd64", "x86_64"}, "386": {"386", "i386"}, "arm64": {"arm64", "aarch64"}}[runtime.GOARCH]
+	if arches == nil {
+		arches = []string{runtime.GOARCH}
+	}
+	for _, arch := range arches {
+		if strings.Contains(name, "_"+runtime.GOOS+"_"+arch) {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedSum finds name in a sha256sum style list.
+func expectedSum(sums []byte, name string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0])
+		}
+	}
+	return ""
+}
+
+func extractBinary(name string, archive []byte) ([]byte, error) {
+	want := "ghreview"
+	if runtime.GOOS == "windows" {
+		want += ".exe"
+	}
+
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) == want {
+				r, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer r.Close()
+				return io.ReadAll(r)
+			}
+		}
+		return nil, fmt.Errorf("no %s in it", want)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no %s in it", want)
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == want {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable swaps binary in for the running executable.  Windows
+// won't let us overwrite a running executable, but will let us move it out
+// of the way, so that's what we do everywhere.
+func replaceExecutable(binary []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	next := exe + ".new"
+	if err := os.WriteFile(next, binary, info.Mode().Perm()); err != nil {
+		return err
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(next)
+		return err
+	}
+	if err := os.Rename(next, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	os.Remove(old)
+	return nil
+}
@@ -0,0 +1,163 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"strings"
+	"syscall/js"
+)
+
+//
+// Built for the browser, ghreview generates reports client-side, so a
+// static page can offer them without a server ever seeing anybody's token:
+//
+//	GOOS=js GOARCH=wasm go build -o web/ghreview.wasm .
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" web/
+//
+// and serve web/.  The page gets a ghreview function, which takes the
+// token, the config (as an object, like ghreview.json) and the usual report
+// flags and repos, and resolves to the report:
+//
+//	const html = await ghreview({token, config: {}, args: ["--year", "2021", "owner/repo"]});
+//
+// net/http goes through the browser's fetch in this build, and the cache
+// lives in localStorage, so a second run is quick; localStorage only holds a
+// few megabytes, though, so it's best for a handful of repos.  Nothing here
+// can read files, so notes, ignore files and git stats aren't available.  A
+// failed run ends the program like it would on the command line; reload the
+// page to try again.
+//
+
+const localStoragePrefix string = "ghreview:"
+
+type localStorageCache struct {
+	storage js.Value
+}
+
+func (c localStorageCache) Get(key string) ([]byte, error) {
+	v := c.storage.Call("getItem", localStoragePrefix+key)
+	if v.IsNull() {
+		return nil, errCacheMiss
+	}
+	return base64.StdEncoding.DecodeString(v.String())
+}
+
+func (c localStorageCache) Put(key string, data []byte) error {
+	var err error
+	func() {
+		//
+		// setItem throws when the storage is full, which syscall/js turns
+		// into a panic.
+		//
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New("localStorage is full")
+			}
+		}()
+		c.storage.Call("setItem", localStoragePrefix+key, base64.StdEncoding.EncodeToString(data))
+	}()
+	return err
+}
+
+func (c localStorageCache) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for i := 0; i < c.storage.Get("length").Int(); i++ {
+		if key, ok := strings.CutPrefix(c.storage.Call("key", i).String(), localStoragePrefix); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func init() {
+	browserMain = func() {
+		js.Global().Set("ghreview", js.FuncOf(func(this js.Value, args []js.Value) any {
+			return promise(func() (string, error) { return browserReport(args[0]) })
+		}))
+		select {}
+	}
+}
+
+// promise runs f in the background, and resolves to what it returns.
+func promise(f func() (string, error)) js.Value {
+	return js.Global().Get("Promise").New(js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			result, err := f()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+		return nil
+	}))
+}
+
+func browserReport(options js.Value) (string, error) {
+	config = Config{}
+	if c := options.Get("config"); c.Truthy() {
+		data := js.Global().Get("JSON").Call("stringify", c).String()
+		if err := json.Unmarshal([]byte(data), &config); err != nil {
+			return "", err
+		}
+	}
+	if token := options.Get("token"); token.Truthy() {
+		if config.Hosts == nil {
+			config.Hosts = map[string]HostConfig{}
+		}
+		hc := config.Hosts[defaultHost]
+		hc.Token = token.String()
+		config.Hosts[defaultHost] = hc
+	}
+
+	var args []string
+	if a := options.Get("args"); a.Truthy() {
+		for i := 0; i < a.Length(); i++ {
+			args = append(args, a.Index(i).String())
+		}
+	}
+
+	//
+	// The same flags that decide what counts as on the command line, so
+	// that the same arguments make the same report.  The ones that may be
+	// repeated add to what's there, so start them afresh for each report.
+	//
+	mentees, baseBranches, pathGlobs = nil, nil, nil
+	flags := flag.NewFlagSet("ghreview", flag.ContinueOnError)
+	opts := addReportFlags(flags)
+	addCollectionFlags(flags)
+	flags.DurationVar(&minInterval, "min-interval", 0, "the least time between requests to the same host")
+	markdown := flags.Bool("markdown", false, "render Markdown instead of HTML")
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if len(flags.Args()) == 0 {
+		return "", flag.ErrHelp
+	}
+
+	cache = localStorageCache{js.Global().Get("localStorage")}
+	hosts = map[string]*Host{}
+	rules = nil
+	checkSchema()
+
+	results := opts.collect(flags.Args())
+	var buf bytes.Buffer
+	var err error
+	if *markdown {
+		err = renderMarkdown(&buf, results, *opts.year)
+	} else {
+		err = renderHTML(&buf, results, *opts.year)
+	}
+	if err != nil {
+		return "", err
+	}
+	log.Printf("rendered a report on %d repos", len(results))
+	return buf.String(), nil
+}
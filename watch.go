@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+//
+// Watching runs the same collection as the report over and over, with a
+// short TTL so that we notice new activity, and tells us about anything that
+// wasn't there the last time round.  The first round just sets the baseline.
+//
+
+func watchMain(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := flags.Duration("interval", 10*time.Minute, "how often to poll")
+	logPath := flags.String("log", "", "append notifications to this file")
+	desktop := flags.Bool("desktop", true, "send desktop notifications")
+	parseFlags(flags, args)
+
+	repos := flags.Args()
+	if len(repos) == 0 {
+		log.Fatal("usage: ghreview watch [--interval 10m] [--log file] owner/repo...")
+	}
+	if ttl == 0 {
+		ttl = *interval
+	}
+
+	seen := map[string]bool{}
+	for round := 0; ; round++ {
+		year := time.Now().Year()
+		for _, repo := range repos {
+			for _, p := range collectRepo(repo, year).Pulls {
+				key := fmt.Sprintf("%s#%d %s", repo, p.Number, p.MyContribution)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if round > 0 {
+					notify(fmt.Sprintf("%s #%d %s", repo, p.Number, p.MyContribution), p.Title, *logPath, *desktop)
+				}
+			}
+		}
+		time.Sleep(*interval)
+	}
+}
+
+func notify(title string, body string, logPath string, desktop bool) {
+	log.Printf("%s: %s", title, body)
+
+	if logPath != "" {
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Fatalf("unable to open %s: %s", logPath, err)
+		}
+		fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), title, body)
+		f.Close()
+	}
+
+	if !desktop {
+		return
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", body, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("notify-send", title, body)
+	default:
+		return
+	}
+	if err := cmd.Run(); err != nil {
+		log.Printf("unable to send a desktop notification: %s", err)
+	}
+}
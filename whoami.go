@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//
+// Fetching for an hour under the wrong identity, or with a token that can't
+// see private repos, is a miserable way to find out about it.  `ghreview
+// whoami` says who each host thinks we are, and what the token may do,
+// right away.
+//
+
+func whoamiMain(args []string) {
+	flags := flag.NewFlagSet("whoami", flag.ExitOnError)
+	var only stringList
+	flags.Var(&only, "host", "only ask this host, e.g. github.example.com (may be repeated)")
+	parseFlags(flags, args)
+
+	names := []string(only)
+	if len(names) == 0 {
+		names = configuredHosts()
+	}
+
+	for _, name := range names {
+		host := hostFor(name)
+		fmt.Printf("%s (%s)\n", name, host.API)
+		if host.Token == "" {
+			fmt.Println("  no token: anonymous, public repos only, 60 requests an hour")
+			continue
+		}
+
+		ctx := context.WithValue(context.Background(), bypassCache{}, true)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, host.API+"/user", nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			fmt.Println("  the token was rejected: it's wrong, expired or revoked")
+			continue
+		} else if resp.StatusCode > 299 {
+			log.Fatalf("GET %s/user: HTTP %d: %s", host.API, resp.StatusCode, data)
+		}
+
+		var me struct {
+			Login string
+			Name  string
+		}
+		if err := json.Unmarshal(data, &me); err != nil {
+			log.Fatalf("JSON unmarshalling failed: %s", err)
+		}
+		fmt.Printf("  login: %s", me.Login)
+		if me.Name != "" {
+			fmt.Printf(" (%s)", me.Name)
+		}
+		fmt.Println()
+
+		//
+		// Classic tokens list their scopes; fine-grained ones and app tokens
+		// don't, their permissions are per repo.
+		//
+		if scopes, ok := resp.Header["X-Oauth-Scopes"]; ok {
+			list := strings.TrimSpace(strings.Join(scopes, ","))
+			if list == "" {
+				list = "none, public data only"
+			}
+			fmt.Printf("  scopes: %s\n", list)
+		} else {
+			fmt.Println("  scopes: not listed (fine-grained or app token), permissions are set per repo")
+		}
+		if !isMe(me.Login) {
+			fmt.Printf("  warning: reporting on %s, but the token belongs to %s\n", user, me.Login)
+		}
+	}
+}
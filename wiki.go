@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//
+// Documentation that lives in a repo's wiki doesn't go through PRs, so none
+// of it shows up otherwise.  With --wiki, for repos that have a wiki, we
+// keep a clone of the wiki's git repo under wikis/ in the cache directory
+// (without file contents, since we only need the history) and count my edits
+// during the period.  A Redis cache has no directory to put them in, so then
+// they go in the default cache directory instead.  Wiki commits made in the
+// browser are by my name and my noreply address, so besides the identities
+// in the config, we recognize those.
+//
+
+var wikiEdits bool
+
+type WikiEdits struct {
+	Edits int
+	Pages int
+}
+
+var noreplyEmail = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.`)
+
+type wikiMetadata struct {
+	HasWiki bool `json:"has_wiki"`
+}
+
+// wikiDir is where the clone of repo's wiki goes.
+func wikiDir(repo string) string {
+	host, name := splitRepo(repo)
+	dir := cacheURL
+	if strings.HasPrefix(dir, "redis://") {
+		dir = defaultCacheDir()
+	}
+	return filepath.Join(dir, "wikis", host.Name, name+".wiki.git")
+}
+
+// gitWithToken runs git with host's token, if any, without putting it on the
+// command line for everybody to see.
+func gitWithToken(host *Host, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if host.Token != "" {
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + host.Token))
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=Authorization: Basic "+basic)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// syncWiki clones or updates repo's wiki, and says whether there is one.  A
+// wiki that's switched on but has no pages yet has no git repo either.
+func syncWiki(repo string) (string, bool) {
+	var meta wikiMetadata
+	if !fetchOptional(repoURL(repo, ""), &meta) || !meta.HasWiki {
+		return "", false
+	}
+	host, name := splitRepo(repo)
+	dir := wikiDir(repo)
+	if _, err := os.Stat(dir); err == nil {
+		if err := gitWithToken(host, "-C", dir, "fetch", "--quiet", "--prune"); err != nil {
+			log.Printf("unable to update the wiki of %s, counting what we have: %s", repo, err)
+		}
+		return dir, true
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		log.Fatal(err)
+	}
+	remote := fmt.Sprintf("https://%s/%s.wiki.git", host.Name, name)
+	if err := gitWithToken(host, "clone", "--quiet", "--mirror", "--filter=blob:none", remote, dir); err != nil {
+		log.Printf("no wiki to read for %s: %s", repo, err)
+		return "", false
+	}
+	return dir, true
+}
+
+// isMyCommit says whether a commit by name and email was mine.
+func isMyCommit(name string, email string) bool {
+	if personForEmail(email, name) == user || isMe(name) {
+		return true
+	}
+	m := noreplyEmail.FindStringSubmatch(email)
+	return m != nil && isMe(m[1])
+}
+
+func loadWikiEdits(repo string, year int) *WikiEdits {
+	dir, ok := syncWiki(repo)
+	if !ok {
+		return nil
+	}
+	first, _ := periodBounds(year)
+	out, err := exec.Command("git", "-C", dir, "log", "--name-only", "--since="+first.Format(time.RFC3339), "--format=%x00%aN%x00%aI%x00%aE").Output()
+	if err != nil {
+		log.Fatalf("unable to read the history of the wiki in %s: %s", dir, err)
+	}
+
+	edits := &WikiEdits{}
+	pages := map[string]bool{}
+	mine := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			fields := strings.Split(line, "\x00")
+			authored, err := time.Parse(time.RFC3339, fields[2])
+			mine = err == nil && inPeriod(authored, year) && isMyCommit(fields[1], fields[3])
+			if mine {
+				edits.Edits++
+			}
+			continue
+		}
+		if mine && line != "" {
+			pages[line] = true
+		}
+	}
+	edits.Pages = len(pages)
+	if edits.Edits == 0 {
+		return nil
+	}
+	return edits
+}